@@ -2,58 +2,239 @@ package app
 
 import (
 	"context"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/http"
 	"time"
 
+	"github.com/redis/go-redis/v9"
+	"github.com/yosa12978/echoes/cache"
 	"github.com/yosa12978/echoes/config"
 	"github.com/yosa12978/echoes/data"
+	"github.com/yosa12978/echoes/jobs"
 	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/markdown"
+	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/services"
 	"github.com/yosa12978/echoes/session"
+	"github.com/yosa12978/echoes/tasks"
+	"github.com/yosa12978/echoes/types"
 )
 
-func Run() error {
-	ctx, cancel := signal.NotifyContext(
-		context.Background(),
-		os.Interrupt,
-		syscall.SIGTERM,
-	)
-	defer cancel()
+// App wires storage, caches and services together. It's shared by the web
+// server and the command line, so both go through the same services.
+type App struct {
+	Config config.Config
+	Logger logging.Logger
+	Redis  *redis.Client
+	Runner tasks.Runner
+	Queue  jobs.Queue
+	Caches cache.Flusher
+	// Markdown renders posts, its stylesheet is served for highlighted code
+	Markdown *markdown.Renderer
+
+	Accounts services.Account
+	Announce services.Announce
+	Backup   services.Backup
+	Comments services.Comment
+	Feed     services.Feed
+	Health   services.HealthService
+	Links    services.Link
+	Media    services.Media
+	Posts    services.Post
+	Preview  services.Preview
+	Profile  services.Profile
+	Sitemap  services.Sitemap
+	WebSub   services.WebSub
 
-	logger := logging.NewJsonLogger(os.Stdout)
+	store *storage
+}
 
-	conn := data.Postgres()
-	defer conn.Close()
+func New(ctx context.Context, cfg config.Config, logger logging.Logger) (*App, error) {
+	store, err := newStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Storage.AutoMigrate {
+		if err := autoMigrate(ctx, store, logger); err != nil {
+			store.db.Close()
+			return nil, err
+		}
+	}
 
 	rdb := data.Redis(ctx)
-	defer rdb.Close()
+	retries := -1 // keeps the default of the runner
+	if cfg.Tasks.Retries != nil {
+		retries = *cfg.Tasks.Retries
+	}
+	runner := tasks.NewRunner(
+		logger,
+		tasks.WithWorkers(cfg.Tasks.Workers),
+		tasks.WithQueueSize(cfg.Tasks.QueueSize),
+		tasks.WithRetries(retries),
+	)
+	queue := jobs.NewQueue(
+		store.jobs,
+		logger,
+		jobs.WithWorkers(cfg.Jobs.Workers),
+		jobs.WithPollInterval(cfg.Jobs.PollInterval),
+		jobs.WithMaxAttempts(cfg.Jobs.MaxAttempts),
+	)
 
-	session.SetupStore()
+	mediaStore, err := newMediaStore(ctx, cfg)
+	if err != nil {
+		store.db.Close()
+		return nil, err
+	}
+	maxSize := cfg.Media.MaxSize
+	if maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+	imageWidths := cfg.Media.ImageWidths
+	if len(imageWidths) == 0 {
+		imageWidths = []int{480, 960, 1600}
+	}
+	mediaService := services.NewMedia(store.media, mediaStore, logger, maxSize, imageWidths)
 
-	cfg := config.Get()
-	server := newServer(
-		ctx,
-		cfg.Server.Addr,
+	markdownOptions := []markdown.Option{
+		markdown.WithHighlightStyle(cfg.Markdown.HighlightStyle),
+		markdown.WithLineNumbers(cfg.Markdown.LineNumbers),
+		markdown.WithTOC(cfg.Markdown.TOCMinHeadings),
+		markdown.WithImages(mediaService),
+	}
+	markdownCache := cache.NewMarkdownRedis(rdb)
+
+	a := &App{
+		Config:   cfg,
+		Logger:   logger,
+		Redis:    rdb,
+		Runner:   runner,
+		Queue:    queue,
+		Caches:   cache.NewFlusherRedis(rdb),
+		Markdown: markdown.New(markdownOptions...),
+		Media:    mediaService,
+		store:    store,
+	}
+	a.Posts = services.NewPost(
+		store.posts,
+		cache.NewPostRedis(rdb, logger),
 		logger,
+		store.searcher,
+		runner,
+		queue,
+		services.NewMarkdown(a.Markdown, markdownCache, logger, runner),
 	)
+	a.Links = services.NewLink(
+		store.links,
+		cache.NewLinkRedis(rdb, logger),
+		logger,
+		runner,
+	)
+	a.Comments = services.NewComment(
+		store.comments,
+		a.Posts,
+		cache.NewCommentRedis(rdb, logger),
+		logger,
+		runner,
+	)
+	announceRepo := repos.NewAnnounceCacheAdapter(cache.NewAnnounceRedis(rdb))
+	a.Announce = services.NewAnnounce(
+		announceRepo,
+		logger,
+	)
+	a.Health = services.NewHealthService(
+		logger,
+		store.pinger,
+		data.NewRedisPinger(ctx),
+	)
+	a.Accounts = services.NewAccount(store.accounts)
+	a.Profile = services.NewProfile(repos.NewProfileFromConfig())
+	a.Feed = services.NewFeedService(
+		a.Posts,
+		a.Comments,
+		// feed readers don't load the site stylesheet
+		services.NewMarkdown(
+			markdown.New(append(markdownOptions, markdown.WithInlineStyles())...),
+			markdownCache,
+			logger,
+			runner,
+		),
+		cache.NewVersionedRedis[types.Feed](rdb, logger, "feed"),
+		logger,
+		runner,
+	)
+	a.Sitemap = services.NewSitemap(
+		store.posts,
+		cache.NewVersionedRedis[types.Sitemap](rdb, logger, "sitemap"),
+		logger,
+		runner,
+	)
+	a.Preview = services.NewPreview(
+		a.Posts,
+		cache.NewPreviewRedis(rdb),
+		logger,
+		runner,
+	)
+	maxLease := cfg.WebSub.Lease
+	if maxLease <= 0 {
+		maxLease = 10 * 24 * time.Hour
+	}
+	a.WebSub = services.NewWebSub(store.websub, a.Feed, queue, logger, maxLease)
+	a.Backup = services.NewBackup(
+		store.posts,
+		store.comments,
+		store.links,
+		store.accounts,
+		announceRepo,
+		repos.NewTransactor(store.db),
+		a.Caches,
+		a.Posts,
+		logger,
+	)
+	return a, nil
+}
+
+// Serve seeds the root account, starts the job queue and serves http until
+// ctx is cancelled.
+func (a *App) Serve(ctx context.Context) error {
+	if err := a.Accounts.Seed(ctx); err != nil {
+		return err
+	}
+	session.SetupStore()
+
+	server := http.Server{
+		Addr:    a.Config.Server.Addr,
+		Handler: a.handler(),
+	}
+	a.Queue.Start(context.Background())
 
 	errCh := make(chan error, 1)
 	go func() {
-		logger.Info("server listening", "addr", cfg.Server.Addr)
+		a.Logger.Info("server listening", "addr", a.Config.Server.Addr)
 		if err := server.ListenAndServe(); err != nil {
 			errCh <- err
 		}
 		close(errCh)
 	}()
 
-	var err error
 	select {
-	case err = <-errCh:
+	case err := <-errCh:
+		return err
 	case <-ctx.Done():
 		timeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 		defer cancel()
-		err = server.Shutdown(timeout)
+		err := server.Shutdown(timeout)
+		if qerr := a.Queue.Stop(timeout); qerr != nil {
+			a.Logger.Error("failed to stop job queue", "error", qerr.Error())
+		}
+		return err
+	}
+}
+
+// Close drains background tasks and closes database connections.
+func (a *App) Close(ctx context.Context) error {
+	if err := a.Runner.Shutdown(ctx); err != nil {
+		a.Logger.Error("failed to drain background tasks", "error", err.Error())
 	}
-	return err
+	a.Logger.Info("background tasks drained", "stats", a.Runner.Stats())
+	a.Redis.Close()
+	return a.store.db.Close()
 }
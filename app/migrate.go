@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/migrations"
+)
+
+// Migrate opens the configured database and passes a migrator for it to fn.
+// It doesn't need redis, so it works before the rest of the app is up.
+func Migrate(cfg config.Config, fn func(m *migrations.Migrator) error) error {
+	store, err := newStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.db.Close()
+	migrator, err := store.migrator()
+	if err != nil {
+		return err
+	}
+	return fn(migrator)
+}
+
+// autoMigrate applies pending migrations on startup. Instances sharing a
+// postgres database wait on an advisory lock instead of racing each other.
+func autoMigrate(ctx context.Context, store *storage, logger logging.Logger) error {
+	migrator, err := store.migrator()
+	if err != nil {
+		return err
+	}
+	n, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("auto migrate: %w", err)
+	}
+	logger.Info("database migrated", "applied", n)
+	return nil
+}
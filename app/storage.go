@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/data"
+	"github.com/yosa12978/echoes/media"
+	"github.com/yosa12978/echoes/migrations"
+	"github.com/yosa12978/echoes/repos"
+)
+
+// storage groups the repositories of a single database backend
+type storage struct {
+	db       *sql.DB
+	sqlite   bool
+	posts    repos.Post
+	comments repos.Comment
+	links    repos.Link
+	accounts repos.Account
+	jobs     repos.Job
+	media    repos.Media
+	websub   repos.Subscription
+	searcher repos.PostSearcher
+	pinger   data.Pinger
+}
+
+func newStorage(cfg config.Config) (*storage, error) {
+	switch cfg.Storage.Driver {
+	case "", "postgres":
+		return &storage{
+			db:       data.Postgres(),
+			posts:    repos.NewPostPostgres(),
+			comments: repos.NewCommentPostgres(),
+			links:    repos.NewLinkPostgres(),
+			accounts: repos.NewAccountPostgres(),
+			jobs:     repos.NewJobPostgres(),
+			media:    repos.NewMediaPostgres(),
+			websub:   repos.NewSubscriptionPostgres(),
+			searcher: repos.NewPostSearcherPostgres(),
+			pinger:   data.NewPgPinger(),
+		}, nil
+	case "sqlite":
+		return &storage{
+			db:       data.SQLite(),
+			sqlite:   true,
+			posts:    repos.NewPostSQLite(),
+			comments: repos.NewCommentSQLite(),
+			links:    repos.NewLinkSQLite(),
+			accounts: repos.NewAccountSQLite(),
+			jobs:     repos.NewJobSQLite(),
+			media:    repos.NewMediaSQLite(),
+			websub:   repos.NewSubscriptionSQLite(),
+			searcher: repos.NewPostSearcherSQLite(),
+			pinger:   data.NewSQLitePinger(),
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+}
+
+func (s *storage) migrator() (*migrations.Migrator, error) {
+	if s.sqlite {
+		return migrations.NewSQLite(s.db)
+	}
+	return migrations.NewPostgres(s.db)
+}
+
+func newMediaStore(ctx context.Context, cfg config.Config) (media.Store, error) {
+	switch cfg.Media.Driver {
+	case "", "local":
+		dir := cfg.Media.Path
+		if dir == "" {
+			dir = "uploads"
+		}
+		return media.NewLocal(dir), nil
+	case "s3":
+		s3 := cfg.Media.S3
+		return media.NewS3(ctx, media.S3Options{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			UseSSL:    s3.UseSSL,
+			PublicURL: s3.PublicURL,
+		})
+	}
+	return nil, fmt.Errorf("unknown media driver %q", cfg.Media.Driver)
+}
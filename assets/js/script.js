@@ -17,3 +17,49 @@ function toDateString_(dateISO8601, timezone = 'GMT') {
 function renderMarkdown(md) {
     return DOMPurify.sanitize(marked.parse(md), { ADD_TAGS: ["iframe"], ADD_ATTR: ['allow', 'allowfullscreen', 'frameborder', 'scrolling'] });
 }
+
+// Embeds of third party content are placeholders until clicked, so nothing
+// is loaded from the third party before the reader asks for it
+document.addEventListener("click", (event) => {
+    const link = event.target.closest(".embed .embed-load")
+    if (!link) {
+        return
+    }
+    const embed = link.closest(".embed")
+    const frame = document.createElement("iframe")
+    frame.title = embed.dataset.embedTitle || ""
+    frame.width = "100%"
+    if (embed.dataset.embedSrc) {
+        frame.src = embed.dataset.embedSrc
+        frame.height = "360"
+        frame.allow = "autoplay; encrypted-media; picture-in-picture; fullscreen"
+        frame.allowFullscreen = true
+    } else if (embed.dataset.embedScript) {
+        // scripts run sandboxed, away from the site
+        frame.sandbox = "allow-scripts allow-popups"
+        frame.height = "400"
+        frame.srcdoc = '<base target="_blank"><script src="' + encodeURI(embed.dataset.embedScript) + '"></scr' + 'ipt>'
+    } else {
+        return
+    }
+    event.preventDefault()
+    frame.style.border = "0"
+    embed.replaceChildren(frame)
+})
+
+// useSnippet inserts the Markdown of an upload into the post editor when
+// the page has one and copies it otherwise
+function useSnippet(button) {
+    const snippet = button.dataset.snippet
+    const editor = document.getElementById("post-editor")
+    if (!editor) {
+        navigator.clipboard.writeText(snippet)
+        button.innerText = "Copied"
+        return
+    }
+    const start = editor.selectionStart
+    editor.value = editor.value.slice(0, start) + snippet + editor.value.slice(editor.selectionEnd)
+    editor.selectionStart = editor.selectionEnd = start + snippet.length
+    editor.dispatchEvent(new Event("input"))
+    editor.focus()
+}
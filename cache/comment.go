@@ -14,7 +14,7 @@ import (
 )
 
 type Comment interface {
-	GetPostComments(ctx context.Context, postId string, page int) (*types.Page[types.Comment], int64, error)
+	GetPostComments(ctx context.Context, postId string, page, size int) (*types.Page[types.Comment], int64, error)
 	GetCommentById(ctx context.Context, id string) (*types.Comment, error)
 	AddPostComments(ctx context.Context, postId string, page int, comment types.Page[types.Comment]) error
 	AddComment(ctx context.Context, comment types.Comment) error
@@ -80,7 +80,8 @@ func (c *commentRedis) AddPostComments(
 	if err != nil {
 		c.logger.Error(err.Error())
 	}
-	key := fmt.Sprintf("comments:%s:%v:%d", postId, version, page)
+	// pages of different sizes start at different comments
+	key := fmt.Sprintf("comments:%s:%v:%d:%d", postId, version, comments.Size, page)
 	pageJson, _ := json.Marshal(comments)
 	err = c.rdb.Set(ctx, key, pageJson, 1*time.Minute).Err()
 	if err != nil {
@@ -89,12 +90,12 @@ func (c *commentRedis) AddPostComments(
 	return nil
 }
 
-func (c *commentRedis) GetPostComments(ctx context.Context, postId string, page int) (*types.Page[types.Comment], int64, error) {
+func (c *commentRedis) GetPostComments(ctx context.Context, postId string, page, size int) (*types.Page[types.Comment], int64, error) {
 	version, err := c.getPaginationVersion(ctx, postId)
 	if err != nil {
 		return nil, version, err
 	}
-	key := fmt.Sprintf("comments:%s:%v:%d", postId, version, page)
+	key := fmt.Sprintf("comments:%s:%v:%d:%d", postId, version, size, page)
 	pageJson, err := c.rdb.Get(ctx, key).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yosa12978/echoes/types"
+)
+
+// cachedKeys match everything the caches in this package write. The
+// announcement lives in redis too, but it isn't a cache, so it's left alone.
+var cachedKeys = []string{
+	"posts*",
+	"comments*",
+	"links*",
+	"markdown*",
+	"feed*",
+	"sitemap*",
+	"preview*",
+}
+
+type Flusher interface {
+	// Flush removes every cached post, comment, link, rendered post, feed,
+	// sitemap and preview image and returns the number of deleted keys.
+	Flush(ctx context.Context) (int, error)
+}
+
+type flusherRedis struct {
+	rdb *redis.Client
+}
+
+func NewFlusherRedis(rdb *redis.Client) Flusher {
+	return &flusherRedis{rdb: rdb}
+}
+
+func (f *flusherRedis) Flush(ctx context.Context) (int, error) {
+	deleted := 0
+	for _, pattern := range cachedKeys {
+		iter := f.rdb.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			n, err := f.rdb.Del(ctx, iter.Val()).Result()
+			if err != nil {
+				return deleted, types.NewErrInternalFailure(err)
+			}
+			deleted += int(n)
+		}
+		if err := iter.Err(); err != nil {
+			return deleted, types.NewErrInternalFailure(err)
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yosa12978/echoes/types"
+)
+
+// Markdown keeps rendered documents by the key of their source, so each
+// version of a post is only rendered once
+type Markdown interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key, value string) error
+}
+
+type markdownRedis struct {
+	rdb *redis.Client
+}
+
+func NewMarkdownRedis(rdb *redis.Client) Markdown {
+	return &markdownRedis{rdb: rdb}
+}
+
+func (m *markdownRedis) Get(ctx context.Context, key string) (string, error) {
+	value, err := m.rdb.Get(ctx, "markdown:"+key).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", types.ErrNotFound
+		}
+		return "", types.NewErrInternalFailure(err)
+	}
+	return value, nil
+}
+
+func (m *markdownRedis) Set(ctx context.Context, key, value string) error {
+	if err := m.rdb.Set(ctx, "markdown:"+key, value, 7*24*time.Hour).Err(); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html/template"
 	"strconv"
 	"time"
 
@@ -44,6 +45,8 @@ func (p *postRedis) GetPostById(ctx context.Context, id string) (*types.Post, er
 	pinned, _ := strconv.ParseBool(postMap["pinned"])
 	tweet, _ := strconv.ParseBool(postMap["tweet"])
 	comments, _ := strconv.Atoi(postMap["comments"])
+	draft, _ := strconv.ParseBool(postMap["draft"])
+	toc, _ := strconv.ParseBool(postMap["toc"])
 	post := types.Post{
 		Id:       postMap["id"],
 		Title:    postMap["title"],
@@ -51,25 +54,41 @@ func (p *postRedis) GetPostById(ctx context.Context, id string) (*types.Post, er
 		Created:  postMap["created"],
 		Pinned:   pinned,
 		Tweet:    tweet,
+		Tags:     types.SplitTags(postMap["tags"]),
+		Draft:    draft,
+		TOC:      toc,
 		Comments: comments,
+		HTML:     template.HTML(postMap["html"]),
+		TOCHTML:  template.HTML(postMap["toc_html"]),
 	}
 	return &post, nil
 }
 
 func (p *postRedis) refreshPaginationVersion(ctx context.Context) (int64, error) {
+	return refreshPaginationVersion(ctx, p.rdb, p.logger)
+}
+
+func (p *postRedis) getPaginationVersion(ctx context.Context) (int64, error) {
+	return getPaginationVersion(ctx, p.rdb, p.logger)
+}
+
+// The pagination version changes whenever posts do, so keys that include it
+// are left behind by any change. It is also the time pages are read at. It
+// never expires, so what's cached under it stays until the posts change.
+func refreshPaginationVersion(ctx context.Context, rdb *redis.Client, logger logging.Logger) (int64, error) {
 	version := time.Now().UnixMicro()
-	res, err := p.rdb.Set(ctx, "posts_pagination_version", version, 1*time.Minute).Result()
+	res, err := rdb.Set(ctx, "posts_pagination_version", version, 0).Result()
 	if res != "OK" || err != nil {
 		return 0, fmt.Errorf("failed to update posts_pagination_version: %w", types.ErrInternalFailure)
 	}
-	p.logger.Info("updated posts_pagination_version", "version", version)
+	logger.Info("updated posts_pagination_version", "version", version)
 	return version, err
 }
 
-func (p *postRedis) getPaginationVersion(ctx context.Context) (int64, error) {
-	versionFromCache, err := p.rdb.Get(ctx, "posts_pagination_version").Result()
+func getPaginationVersion(ctx context.Context, rdb *redis.Client, logger logging.Logger) (int64, error) {
+	versionFromCache, err := rdb.Get(ctx, "posts_pagination_version").Result()
 	if err != nil {
-		return p.refreshPaginationVersion(ctx)
+		return refreshPaginationVersion(ctx, rdb, logger)
 	}
 	return strconv.ParseInt(versionFromCache, 10, 64)
 }
@@ -77,8 +96,9 @@ func (p *postRedis) getPaginationVersion(ctx context.Context) (int64, error) {
 // latency here 2ms+ size=20
 func (p *postRedis) GetPostsByPage(ctx context.Context, page int, size int) (*types.Page[types.Post], int64, error) {
 	version, _ := p.getPaginationVersion(ctx)
-	valueKey := fmt.Sprintf("posts:%v:page:%d", version, page)
-	metaKey := fmt.Sprintf("posts:%v:page_meta:%d", version, page)
+	// pages of different sizes start at different posts
+	valueKey := fmt.Sprintf("posts:%v:page:%d:%d", version, size, page)
+	metaKey := fmt.Sprintf("posts:%v:page_meta:%d:%d", version, size, page)
 	//make this concurrent
 	valueExists, _ := p.rdb.Exists(ctx, valueKey).Result()
 	metaExists, _ := p.rdb.Exists(ctx, metaKey).Result()
@@ -149,7 +169,12 @@ func addPost(ctx context.Context, post types.Post, rdb redis.Cmdable) error {
 		"created":  post.Created,
 		"pinned":   post.Pinned,
 		"tweet":    post.Tweet,
+		"tags":     types.JoinTags(post.Tags),
+		"draft":    post.Draft,
+		"toc":      post.TOC,
 		"comments": post.Comments,
+		"html":     string(post.HTML),
+		"toc_html": string(post.TOCHTML),
 	}
 	key := fmt.Sprintf("posts:%s", post.Id)
 	_, err := rdb.HSet(ctx, key, postMap).Result()
@@ -184,14 +209,14 @@ func (p *postRedis) AddPageOfPosts(
 	postsKeysJson, _ := json.Marshal(postsIDs)
 
 	// caching posts sorted set
-	valueKey := fmt.Sprintf("posts:%v:page:%d", version, pageNum)
+	valueKey := fmt.Sprintf("posts:%v:page:%d:%d", version, page.Size, pageNum)
 	err := pipe.Set(ctx, valueKey, postsKeysJson, 2*time.Minute).Err()
 	if err != nil {
 		return types.NewErrInternalFailure(err)
 	}
 
 	// setting up metadata
-	metaKey := fmt.Sprintf("posts:%v:page_meta:%d", version, pageNum)
+	metaKey := fmt.Sprintf("posts:%v:page_meta:%d:%d", version, page.Size, pageNum)
 	metaData := map[string]interface{}{
 		"has_next":  page.HasNext,
 		"next_page": page.NextPage,
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yosa12978/echoes/types"
+)
+
+// Preview keeps generated post preview images. The version in the key
+// changes with everything drawn on the image, so entries never go stale,
+// they just stop being asked for.
+type Preview interface {
+	Get(ctx context.Context, id, version string) ([]byte, error)
+	Set(ctx context.Context, id, version string, image []byte) error
+}
+
+type previewRedis struct {
+	rdb *redis.Client
+}
+
+func NewPreviewRedis(rdb *redis.Client) Preview {
+	return &previewRedis{rdb: rdb}
+}
+
+func previewKey(id, version string) string {
+	return "preview:" + id + ":" + version
+}
+
+func (p *previewRedis) Get(ctx context.Context, id, version string) ([]byte, error) {
+	value, err := p.rdb.Get(ctx, previewKey(id, version)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, types.ErrNotFound
+		}
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return value, nil
+}
+
+func (p *previewRedis) Set(ctx context.Context, id, version string, image []byte) error {
+	if err := p.rdb.Set(ctx, previewKey(id, version), image, 7*24*time.Hour).Err(); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
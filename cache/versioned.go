@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/types"
+)
+
+// Versioned keeps documents generated from the posts, like feeds and
+// sitemaps. They are stored under the posts pagination version, so any
+// change to the posts leaves them behind.
+type Versioned[T any] interface {
+	// Get returns the cached document along with the version it has to be
+	// stored under when it's missing
+	Get(ctx context.Context, key string) (*T, int64, error)
+	Set(ctx context.Context, version int64, key string, value T) error
+}
+
+type versionedRedis[T any] struct {
+	rdb    *redis.Client
+	logger logging.Logger
+	prefix string
+}
+
+// NewVersionedRedis returns a cache writing its keys under prefix, the
+// prefix has to be one of the patterns the flusher removes
+func NewVersionedRedis[T any](rdb *redis.Client, logger logging.Logger, prefix string) Versioned[T] {
+	return &versionedRedis[T]{rdb: rdb, logger: logger, prefix: prefix}
+}
+
+func (v *versionedRedis[T]) key(version int64, key string) string {
+	return fmt.Sprintf("%s:%d:%s", v.prefix, version, key)
+}
+
+func (v *versionedRedis[T]) Get(ctx context.Context, key string) (*T, int64, error) {
+	version, err := getPaginationVersion(ctx, v.rdb, v.logger)
+	if err != nil {
+		return nil, 0, err
+	}
+	value, err := v.rdb.Get(ctx, v.key(version, key)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, version, types.ErrNotFound
+		}
+		return nil, version, types.NewErrInternalFailure(err)
+	}
+	var res T
+	if err := json.Unmarshal([]byte(value), &res); err != nil {
+		return nil, version, types.NewErrInternalFailure(err)
+	}
+	return &res, version, nil
+}
+
+func (v *versionedRedis[T]) Set(ctx context.Context, version int64, key string, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	if err := v.rdb.Set(ctx, v.key(version, key), data, 10*time.Minute).Err(); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/types"
+)
+
+func backupCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("backup export", flag.ContinueOnError)
+		out := fs.String("o", "", "output file, echoes-<date>.zip when empty")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 0 {
+			return errUsage
+		}
+		path := *out
+		if path == "" {
+			path = fmt.Sprintf("echoes-%s.zip", time.Now().Format("2006-01-02"))
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			manifest, err := a.Backup.Export(ctx, f)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("exported %v to %s\n", manifest.Counts, path)
+			return nil
+		})
+	case "import":
+		fs := flag.NewFlagSet("backup import", flag.ContinueOnError)
+		mode := fs.String("mode", types.ImportMerge, "merge or replace")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			report, err := a.Backup.Import(ctx, f, info.Size(), *mode)
+			if err != nil {
+				return err
+			}
+			for _, e := range report.Errors {
+				fmt.Fprintln(os.Stderr, e)
+			}
+			fmt.Println(report)
+			if report.Imported["accounts"] > 0 {
+				fmt.Println("imported accounts have random passwords, set them with: echoes user reset-password <username>")
+			}
+			return nil
+		})
+	}
+	return errUsage
+}
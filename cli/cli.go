@@ -0,0 +1,105 @@
+// Package cli implements the echoes command line. Every command goes through
+// the same services as the web server.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/logging"
+)
+
+var errUsage = errors.New("invalid usage")
+
+const shutdownTimeout = 10 * time.Second
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, cfg config.Config, args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve", serveCmd},
+	{"migrate", "migrate up | down [steps] | status", migrateCmd},
+	{"user", "user create [-admin] [-password p] <username> | list | delete <username> | reset-password [-password p] <username>", userCmd},
+	{"post", "post export [-o file] | import <file> | publish <id>", postCmd},
+	{"import", "import markdown [-dry-run] <dir|archive> | wxr [-dry-run] [-o urls.tsv] <file>", importCmd},
+	{"static", "static export [-o dir]", staticCmd},
+	{"backup", "backup export [-o file] | import [-mode merge|replace] <file>", backupCmd},
+	{"cache", "cache flush", cacheCmd},
+	{"search", "search reindex", searchCmd},
+	{"config", "config check", configCmd},
+}
+
+// Run parses args (without the program name) and runs the matching command.
+// Without a command it serves the website.
+func Run(args []string) error {
+	fs := flag.NewFlagSet("echoes", flag.ContinueOnError)
+	configPath := fs.String("config", "config.yaml", "path to the config file")
+	fs.Usage = func() { usage(fs.Output()) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+	if args[0] == "help" {
+		usage(os.Stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		err = cmd.run(ctx, cfg, args[1:])
+		if errors.Is(err, errUsage) {
+			return fmt.Errorf("usage: echoes %s", cmd.usage)
+		}
+		return err
+	}
+	usage(os.Stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: echoes [-config file] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\n", cmd.usage)
+	}
+}
+
+// withApp builds the app for a single command. Logs go to stderr so they
+// don't mix with command output.
+func withApp(ctx context.Context, cfg config.Config, fn func(a *app.App) error) error {
+	a, err := app.New(ctx, cfg, logging.NewJsonLogger(os.Stderr))
+	if err != nil {
+		return err
+	}
+	err = fn(a)
+	timeout, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return errors.Join(err, a.Close(timeout))
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/importer"
+)
+
+func importCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "markdown":
+		fs := flag.NewFlagSet("import markdown", flag.ContinueOnError)
+		dryRun := fs.Bool("dry-run", false, "only parse the files and show what would be imported")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			report, err := importer.Markdown(ctx, a.Posts, fs.Arg(0), *dryRun)
+			if err != nil {
+				return err
+			}
+			return printReport(report)
+		})
+	case "wxr":
+		fs := flag.NewFlagSet("import wxr", flag.ContinueOnError)
+		dryRun := fs.Bool("dry-run", false, "only parse the file and show what would be imported")
+		out := fs.String("o", "", "write the old to new URL mapping to this file instead of stdout")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		postURL := cfg.Feed.DetailLink
+		if postURL == "" {
+			postURL = "/posts/"
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			report, err := importer.WXR(ctx, a.Posts, a.Comments, fs.Arg(0), postURL, *dryRun)
+			if err != nil {
+				return err
+			}
+			if err := writeRedirects(*out, report.Redirects); err != nil {
+				return err
+			}
+			return printReport(report)
+		})
+	}
+	return errUsage
+}
+
+// printReport writes the report to stderr, stdout is left to the redirects
+// of a WXR import
+func printReport(report *importer.Report) error {
+	for _, res := range report.Results {
+		if res.Err != nil {
+			fmt.Fprintf(os.Stderr, "FAIL %s: %s\n", res.File, res.Err)
+			continue
+		}
+		state := ""
+		if res.Post.Draft && res.Post.Id != "" {
+			state = " (draft " + res.Post.Id + ")"
+		} else if res.Post.Draft {
+			state = " (draft)"
+		}
+		fmt.Fprintf(os.Stderr, "ok   %s: %q %s%s\n", res.File, res.Post.Title, res.Post.Created, state)
+	}
+	for _, c := range report.CommentErrors {
+		fmt.Fprintf(os.Stderr, "FAIL %s: comment of %s from %s: %s\n", c.File, c.Author, c.Created, c.Err)
+	}
+	verb := "imported"
+	if report.DryRun {
+		verb = "would import"
+	}
+	comments := ""
+	if report.Comments > 0 || len(report.CommentErrors) > 0 {
+		comments = fmt.Sprintf(", %d comment(s)", report.Comments)
+	}
+	if len(report.CommentErrors) > 0 {
+		comments += fmt.Sprintf(" (%d failed)", len(report.CommentErrors))
+	}
+	fmt.Fprintf(os.Stderr, "%s %d post(s)%s, %d failed\n", verb, report.Imported(), comments, report.Failed())
+	if report.Failed() > 0 {
+		return fmt.Errorf("%d file(s) failed to import", report.Failed())
+	}
+	if len(report.CommentErrors) > 0 {
+		return fmt.Errorf("%d comment(s) failed to import", len(report.CommentErrors))
+	}
+	return nil
+}
+
+// writeRedirects prints one "old<TAB>new" line per post, ready to be turned
+// into redirect rules
+func writeRedirects(filename string, redirects []importer.Redirect) error {
+	w := os.Stdout
+	if filename != "" {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	for _, r := range redirects {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", r.Old, r.New); err != nil {
+			return err
+		}
+	}
+	return nil
+}
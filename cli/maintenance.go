@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+)
+
+func cacheCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "flush" {
+		return errUsage
+	}
+	return withApp(ctx, cfg, func(a *app.App) error {
+		n, err := a.Caches.Flush(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("flushed %d cached key(s)\n", n)
+		return nil
+	})
+}
+
+func searchCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "reindex" {
+		return errUsage
+	}
+	return withApp(ctx, cfg, func(a *app.App) error {
+		n, err := a.Posts.Reindex(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reindexed %d post(s)\n", n)
+		return nil
+	})
+}
+
+func configCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errUsage
+	}
+	problems := cfg.Validate()
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("config has %d problem(s)", len(problems))
+	}
+	fmt.Println("config is ok")
+	return nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/migrations"
+)
+
+func migrateCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "up":
+		return app.Migrate(cfg, func(m *migrations.Migrator) error {
+			n, err := m.Up(ctx)
+			fmt.Printf("applied %d migration(s)\n", n)
+			return err
+		})
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		return app.Migrate(cfg, func(m *migrations.Migrator) error {
+			n, err := m.Down(ctx, steps)
+			fmt.Printf("reverted %d migration(s)\n", n)
+			return err
+		})
+	case "status":
+		return app.Migrate(cfg, func(m *migrations.Migrator) error {
+			statuses, err := m.Status(ctx)
+			if err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				state := "pending"
+				if s.Applied {
+					state = "applied"
+				}
+				fmt.Printf("%06d  %-8s %s\n", s.Version, state, s.Name)
+			}
+			return nil
+		})
+	}
+	return errUsage
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/types"
+)
+
+// postRecord is the format used by post export and post import
+type postRecord struct {
+	Id      string   `json:"id"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Created string   `json:"created"`
+	Pinned  bool     `json:"pinned"`
+	Tweet   bool     `json:"tweet"`
+	Tags    []string `json:"tags,omitempty"`
+	Draft   bool     `json:"draft,omitempty"`
+	TOC     bool     `json:"toc,omitempty"`
+}
+
+func postCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "export":
+		fs := flag.NewFlagSet("post export", flag.ContinueOnError)
+		out := fs.String("o", "", "output file, stdout when empty")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 0 {
+			return errUsage
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			return exportPosts(ctx, a, *out)
+		})
+	case "import":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			return importPosts(ctx, a, args[1])
+		})
+	case "publish":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			post, err := a.Posts.PublishPost(ctx, args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("published %q\n", post.Title)
+			return nil
+		})
+	}
+	return errUsage
+}
+
+func exportPosts(ctx context.Context, a *app.App, path string) error {
+	posts, err := a.Posts.GetPosts(ctx)
+	if err != nil {
+		return err
+	}
+	records := make([]postRecord, len(posts))
+	for i, p := range posts {
+		records[i] = postRecord{
+			Id:      p.Id,
+			Title:   p.Title,
+			Content: p.Content,
+			Created: p.Created,
+			Pinned:  p.Pinned,
+			Tweet:   p.Tweet,
+			Tags:    p.Tags,
+			Draft:   p.Draft,
+			TOC:     p.TOC,
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(records); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d post(s)\n", len(records))
+	return nil
+}
+
+// importPosts reads posts written by post export ("-" reads stdin). Posts
+// that already exist are skipped.
+func importPosts(ctx context.Context, a *app.App, path string) error {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	records := []postRecord{}
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	imported := 0
+	for _, rec := range records {
+		_, err := a.Posts.ImportPost(ctx, types.Post{
+			Id:      rec.Id,
+			Title:   rec.Title,
+			Content: rec.Content,
+			Created: rec.Created,
+			Pinned:  rec.Pinned,
+			Tweet:   rec.Tweet,
+			Tags:    rec.Tags,
+			Draft:   rec.Draft,
+			TOC:     rec.TOC,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "skipped %q: %s\n", rec.Title, err)
+			continue
+		}
+		imported++
+	}
+	fmt.Fprintf(os.Stderr, "imported %d of %d post(s)\n", imported, len(records))
+	return nil
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/logging"
+)
+
+func serveCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	a, err := app.New(ctx, cfg, logging.NewJsonLogger(os.Stdout))
+	if err != nil {
+		return err
+	}
+	err = a.Serve(ctx)
+	timeout, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if cerr := a.Close(timeout); cerr != nil {
+		a.Logger.Error("failed to close app", "error", cerr.Error())
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/static"
+)
+
+func staticCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "export" {
+		return errUsage
+	}
+	fs := flag.NewFlagSet("static export", flag.ContinueOnError)
+	out := fs.String("o", "public", "directory to write the site to")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	return withApp(ctx, cfg, func(a *app.App) error {
+		report, err := static.Export(ctx, static.Services{
+			Posts:    a.Posts,
+			Comments: a.Comments,
+			Links:    a.Links,
+			Profile:  a.Profile,
+			Announce: a.Announce,
+			Feed:     a.Feed,
+			Media:    a.Media,
+			Preview:  a.Preview,
+			Markdown: a.Markdown,
+		}, *out)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("wrote %d page(s) with %d post(s), %d asset(s) and %d upload(s) to %s\n",
+			report.Pages, report.Posts, report.Assets, report.Media, *out)
+		return nil
+	})
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yosa12978/echoes/app"
+	"github.com/yosa12978/echoes/config"
+)
+
+func userCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+		admin := fs.Bool("admin", false, "grant admin rights")
+		password := fs.String("password", "", "password, read from stdin when empty")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		pass, err := readPassword(*password)
+		if err != nil {
+			return err
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			acc, err := a.Accounts.CreateAccount(ctx, fs.Arg(0), pass, *admin)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("created account %s (%s)\n", acc.Username, acc.Id)
+			return nil
+		})
+	case "list":
+		return withApp(ctx, cfg, func(a *app.App) error {
+			accounts, err := a.Accounts.GetAccounts(ctx)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tUSERNAME\tADMIN\tCREATED")
+			for _, acc := range accounts {
+				fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", acc.Id, acc.Username, acc.IsAdmin, acc.Created)
+			}
+			return w.Flush()
+		})
+	case "delete":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			acc, err := a.Accounts.DeleteAccount(ctx, args[1])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("deleted account %s\n", acc.Username)
+			return nil
+		})
+	case "reset-password":
+		fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+		password := fs.String("password", "", "new password, read from stdin when empty")
+		if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		pass, err := readPassword(*password)
+		if err != nil {
+			return err
+		}
+		return withApp(ctx, cfg, func(a *app.App) error {
+			if err := a.Accounts.ResetPassword(ctx, fs.Arg(0), pass); err != nil {
+				return err
+			}
+			fmt.Printf("password of %s has been reset\n", fs.Arg(0))
+			return nil
+		})
+	}
+	return errUsage
+}
+
+// readPassword returns password or, if it's empty, reads a line from stdin
+// so that passwords don't have to end up in the shell history
+func readPassword(password string) (string, error) {
+	if password != "" {
+		return password, nil
+	}
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -2,15 +2,18 @@ package config
 
 import (
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"gopkg.in/yaml.v3"
 )
 
 var (
-	c    Config
-	once sync.Once
+	c       Config
+	loadErr error
+	once    sync.Once
 )
 
 type Config struct {
@@ -19,6 +22,11 @@ type Config struct {
 		SessionKey string `yaml:"session_key" envconfig:"ECHOES_SESSION_KEY" json:"session_key"`
 		RootPass   string `yaml:"root_pass" envconfig:"ECHOES_ROOT_PASS" json:"root_pass"`
 	} `yaml:"server" json:"server"`
+	Storage struct {
+		Driver      string `yaml:"driver" envconfig:"ECHOES_STORAGE_DRIVER" json:"driver"` // postgres or sqlite
+		Path        string `yaml:"path" envconfig:"ECHOES_STORAGE_PATH" json:"path"`       // sqlite database file
+		AutoMigrate bool   `yaml:"auto_migrate" envconfig:"ECHOES_AUTO_MIGRATE" json:"auto_migrate"`
+	} `yaml:"storage" json:"storage"`
 	Postgres struct {
 		User    string `yaml:"username" envconfig:"ECHOES_POSTGRES_USER" json:"username"`
 		Pass    string `yaml:"password" envconfig:"ECHOES_POSTGRES_PASS" json:"password"`
@@ -45,22 +53,77 @@ type Config struct {
 		Picture string `yaml:"picture" envconfig:"ECHOES_PROFILE_PICTURE" json:"picture"`
 	} `yaml:"profile" json:"profile"`
 	Website struct {
-		Title string `yaml:"title" envconfig:"ECHOES_WEBSITE_TITLE" json:"title"`
-		Logo  string `yaml:"logo" envconfig:"ECHOES_WEBSITE_LOGO" json:"logo"`
-		BgImg string `yaml:"bg_img" envconfig:"ECHOES_WEBSITE_BG_IMG" json:"bg_img"`
+		Title  string `yaml:"title" envconfig:"ECHOES_WEBSITE_TITLE" json:"title"`
+		Logo   string `yaml:"logo" envconfig:"ECHOES_WEBSITE_LOGO" json:"logo"`
+		BgImg  string `yaml:"bg_img" envconfig:"ECHOES_WEBSITE_BG_IMG" json:"bg_img"`
+		URL    string `yaml:"url" envconfig:"ECHOES_WEBSITE_URL" json:"url"`          // public address, like https://website.com
+		Robots string `yaml:"robots" envconfig:"ECHOES_WEBSITE_ROBOTS" json:"robots"` // robots.txt, generated when empty
 	} `yaml:"website" json:"website"`
+	Markdown struct {
+		HighlightStyle string `yaml:"highlight_style" envconfig:"ECHOES_MARKDOWN_HIGHLIGHT_STYLE" json:"highlight_style"` // chroma style or "none"
+		LineNumbers    bool   `yaml:"line_numbers" envconfig:"ECHOES_MARKDOWN_LINE_NUMBERS" json:"line_numbers"`
+		TOCMinHeadings int    `yaml:"toc_min_headings" envconfig:"ECHOES_MARKDOWN_TOC_MIN_HEADINGS" json:"toc_min_headings"` // 0 leaves it to each post
+	} `yaml:"markdown" json:"markdown"`
+	Media struct {
+		Driver      string `yaml:"driver" envconfig:"ECHOES_MEDIA_DRIVER" json:"driver"`                   // local or s3
+		Path        string `yaml:"path" envconfig:"ECHOES_MEDIA_PATH" json:"path"`                         // directory of the local driver
+		MaxSize     int64  `yaml:"max_size" envconfig:"ECHOES_MEDIA_MAX_SIZE" json:"max_size"`             // bytes per upload
+		ImageWidths []int  `yaml:"image_widths" envconfig:"ECHOES_MEDIA_IMAGE_WIDTHS" json:"image_widths"` // widths uploaded images are resized to
+		S3          struct {
+			Endpoint  string `yaml:"endpoint" envconfig:"ECHOES_S3_ENDPOINT" json:"endpoint"`
+			Region    string `yaml:"region" envconfig:"ECHOES_S3_REGION" json:"region"`
+			Bucket    string `yaml:"bucket" envconfig:"ECHOES_S3_BUCKET" json:"bucket"`
+			AccessKey string `yaml:"access_key" envconfig:"ECHOES_S3_ACCESS_KEY" json:"access_key"`
+			SecretKey string `yaml:"secret_key" envconfig:"ECHOES_S3_SECRET_KEY" json:"secret_key"`
+			UseSSL    bool   `yaml:"use_ssl" envconfig:"ECHOES_S3_USE_SSL" json:"use_ssl"`
+			PublicURL string `yaml:"public_url" envconfig:"ECHOES_S3_PUBLIC_URL" json:"public_url"` // served through echoes when empty
+		} `yaml:"s3" json:"s3"`
+	} `yaml:"media" json:"media"`
+	Tasks struct {
+		Workers   int  `yaml:"workers" envconfig:"ECHOES_TASKS_WORKERS" json:"workers"`
+		QueueSize int  `yaml:"queue_size" envconfig:"ECHOES_TASKS_QUEUE_SIZE" json:"queue_size"`
+		Retries   *int `yaml:"retries" envconfig:"ECHOES_TASKS_RETRIES" json:"retries"` // 2 when unset, 0 turns retries off
+	} `yaml:"tasks" json:"tasks"`
+	Jobs struct {
+		Workers      int           `yaml:"workers" envconfig:"ECHOES_JOBS_WORKERS" json:"workers"`
+		PollInterval time.Duration `yaml:"poll_interval" envconfig:"ECHOES_JOBS_POLL_INTERVAL" json:"poll_interval"`
+		MaxAttempts  int           `yaml:"max_attempts" envconfig:"ECHOES_JOBS_MAX_ATTEMPTS" json:"max_attempts"`
+	} `yaml:"jobs" json:"jobs"`
+	WebSub struct {
+		Hub   string        `yaml:"hub" envconfig:"ECHOES_WEBSUB_HUB" json:"hub"`       // hub url, "builtin" or empty to turn WebSub off
+		Lease time.Duration `yaml:"lease" envconfig:"ECHOES_WEBSUB_LEASE" json:"lease"` // longest subscription the built-in hub grants
+	} `yaml:"websub" json:"websub"`
 }
 
+// Get returns the config loaded from config.yaml and the environment. It
+// panics if the config can't be read.
 func Get() Config {
+	cfg, err := Load("config.yaml")
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// URL returns the absolute url of path on the website, or "" when
+// website.url isn't set
+func (c Config) URL(path string) string {
+	if c.Website.URL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(c.Website.URL, "/") + path
+}
+
+// Load reads the config from filename and the environment. Only the first
+// call reads anything, later calls (and Get) return the same config.
+func Load(filename string) (Config, error) {
 	once.Do(func() {
-		if err := readFile("config.yaml", &c); err != nil {
-			panic(err)
-		}
-		if err := readEnv(&c); err != nil {
-			panic(err)
+		if loadErr = readFile(filename, &c); loadErr != nil {
+			return
 		}
+		loadErr = readEnv(&c)
 	})
-	return c
+	return c, loadErr
 }
 
 func readFile(filename string, cfg *Config) error {
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// Validate returns a list of problems that would keep the server from
+// starting or make it unsafe to run. An empty list means the config is fine.
+func (c Config) Validate() []string {
+	problems := []string{}
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr is empty")
+	}
+	if c.Server.SessionKey == "" || c.Server.SessionKey == "super_secret_session_key" {
+		problems = append(problems, "server.session_key is empty or left at the default value")
+	}
+	if len(c.Server.RootPass) < 4 || strings.Contains(c.Server.RootPass, " ") {
+		problems = append(problems, "server.root_pass must be at least 4 characters long and contain no spaces")
+	}
+	switch c.Storage.Driver {
+	case "", "postgres":
+		if c.Postgres.Addr == "" || c.Postgres.DB == "" {
+			problems = append(problems, "postgres.addr and postgres.db are required by the postgres driver")
+		}
+	case "sqlite":
+	default:
+		problems = append(problems, fmt.Sprintf("storage.driver %q is unknown, use postgres or sqlite", c.Storage.Driver))
+	}
+	if c.Redis.Addr == "" {
+		problems = append(problems, "redis.addr is empty")
+	}
+	if style := c.Markdown.HighlightStyle; style != "" && style != "none" {
+		if _, ok := styles.Registry[style]; !ok {
+			problems = append(problems, fmt.Sprintf("markdown.highlight_style %q isn't a known chroma style", style))
+		}
+	}
+	if c.Markdown.TOCMinHeadings < 0 {
+		problems = append(problems, "markdown.toc_min_headings can't be negative")
+	}
+	switch c.Media.Driver {
+	case "", "local":
+	case "s3":
+		if c.Media.S3.Endpoint == "" || c.Media.S3.Bucket == "" {
+			problems = append(problems, "media.s3.endpoint and media.s3.bucket are required by the s3 driver")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("media.driver %q is unknown, use local or s3", c.Media.Driver))
+	}
+	if c.Media.MaxSize < 0 {
+		problems = append(problems, "media.max_size can't be negative")
+	}
+	for _, width := range c.Media.ImageWidths {
+		if width <= 0 || width > 8192 {
+			problems = append(problems, fmt.Sprintf("media.image_widths: %d isn't between 1 and 8192", width))
+		}
+	}
+	if c.Tasks.Workers < 0 || c.Tasks.QueueSize < 0 || (c.Tasks.Retries != nil && *c.Tasks.Retries < 0) {
+		problems = append(problems, "tasks settings can't be negative")
+	}
+	if c.Jobs.Workers < 0 || c.Jobs.PollInterval < 0 || c.Jobs.MaxAttempts < 0 {
+		problems = append(problems, "jobs settings can't be negative")
+	}
+	if c.Website.URL != "" {
+		if u, err := url.Parse(c.Website.URL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			problems = append(problems, fmt.Sprintf("website.url %q isn't an absolute http(s) url", c.Website.URL))
+		}
+	}
+	if c.WebSub.Hub != "" {
+		if c.Website.URL == "" {
+			problems = append(problems, "websub needs website.url to name the feeds subscribers follow")
+		}
+		if c.WebSub.Hub != "builtin" {
+			if u, err := url.Parse(c.WebSub.Hub); err != nil || u.Host == "" {
+				problems = append(problems, fmt.Sprintf("websub.hub %q is neither a url nor builtin", c.WebSub.Hub))
+			}
+		}
+	}
+	if c.WebSub.Lease < 0 {
+		problems = append(problems, "websub.lease can't be negative")
+	}
+	return problems
+}
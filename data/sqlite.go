@@ -0,0 +1,52 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/yosa12978/echoes/config"
+	_ "modernc.org/sqlite"
+)
+
+var (
+	sqliteDb   *sql.DB
+	sqliteOnce sync.Once
+)
+
+func SQLite() *sql.DB {
+	sqliteOnce.Do(func() {
+		path := config.Get().Storage.Path
+		if path == "" {
+			path = "echoes.db"
+		}
+		dsn := fmt.Sprintf(
+			"file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+			path,
+		)
+		conn, err := sql.Open("sqlite", dsn)
+		if err != nil {
+			panic(err)
+		}
+		if err := conn.Ping(); err != nil {
+			panic(err)
+		}
+		sqliteDb = conn
+	})
+	return sqliteDb
+}
+
+type sqlitePinger struct {
+	db *sql.DB
+}
+
+func NewSQLitePinger() Pinger {
+	return &sqlitePinger{
+		db: sqliteDb,
+	}
+}
+
+func (p *sqlitePinger) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
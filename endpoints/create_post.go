@@ -27,6 +27,7 @@ func CreatePost(logger logging.Logger, service services.Post) http.HandlerFunc {
 			dto.Title,
 			dto.Content,
 			dto.Tweet != "",
+			dto.TOC != "",
 		); err != nil {
 			logger.Error(err.Error())
 			utils.RenderBlock(w, "alert_danger", "Failed to create")
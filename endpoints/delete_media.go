@@ -0,0 +1,20 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func DeleteMedia(logger logging.Logger, service services.Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := service.DeleteMedia(r.Context(), r.PathValue("id")); err != nil {
+			logger.Error(err.Error())
+			utils.RenderBlock(w, "alert_danger", "Failed to delete")
+			return
+		}
+		utils.RenderBlock(w, "alert_success", "File deleted")
+	}
+}
@@ -0,0 +1,44 @@
+package endpoints
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+)
+
+func ExportBackup(logger logging.Logger, service services.Backup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// the archive is written to a temp file first, so a failure can
+		// still be answered with an error instead of a truncated zip
+		tmp, err := os.CreateTemp("", "echoes-backup-*.zip")
+		if err != nil {
+			logger.Error(err.Error())
+			http.Error(w, "failed to export backup", http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if _, err := service.Export(r.Context(), tmp); err != nil {
+			logger.Error(err.Error())
+			http.Error(w, "failed to export backup", http.StatusInternalServerError)
+			return
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			logger.Error(err.Error())
+			http.Error(w, "failed to export backup", http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now()
+		filename := fmt.Sprintf("echoes-%s.zip", now.Format("2006-01-02"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		http.ServeContent(w, r, filename, now, tmp)
+	}
+}
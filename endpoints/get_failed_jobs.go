@@ -0,0 +1,31 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/yosa12978/echoes/jobs"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func GetFailedJobs(logger logging.Logger, queue jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageS := r.URL.Query().Get("page")
+		if pageS == "" {
+			pageS = "1"
+		}
+		page, err := strconv.Atoi(pageS)
+		if err != nil {
+			utils.RenderBlock(w, "alert", "wrong page number")
+			return
+		}
+		failed, err := queue.Failed(r.Context(), page, 20)
+		if err != nil {
+			logger.Error(err.Error())
+			utils.RenderBlock(w, "alert_danger", "can't fetch failed jobs")
+			return
+		}
+		utils.RenderBlock(w, "jobs_failed", failed)
+	}
+}
@@ -1,20 +1,107 @@
 package endpoints
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
 )
 
-func GetFeed(service services.Feed) http.HandlerFunc {
+// The feed handlers serve a feed in format, or in the format the Accept
+// header prefers when format is empty.
+
+func GetFeed(service services.Feed, format services.FeedFormat) http.HandlerFunc {
+	return feedHandler(format, func(r *http.Request, format services.FeedFormat) (*types.Feed, error) {
+		return service.GenerateFeed(r.Context(), format)
+	})
+}
+
+func GetTagFeed(service services.Feed, format services.FeedFormat) http.HandlerFunc {
+	return feedHandler(format, func(r *http.Request, format services.FeedFormat) (*types.Feed, error) {
+		return service.GenerateTagFeed(r.Context(), r.PathValue("tag"), format)
+	})
+}
+
+// GetCommentsFeed serves the comments of the post in the path, or of every
+// post on routes without one
+func GetCommentsFeed(service services.Feed, format services.FeedFormat) http.HandlerFunc {
+	return feedHandler(format, func(r *http.Request, format services.FeedFormat) (*types.Feed, error) {
+		return service.GenerateCommentsFeed(r.Context(), r.PathValue("id"), format)
+	})
+}
+
+func feedHandler(
+	format services.FeedFormat,
+	generate func(r *http.Request, format services.FeedFormat) (*types.Feed, error),
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/atom+xml")
-		feed, err := service.GenerateFeed(r.Context())
+		format := format
+		if format == "" {
+			w.Header().Add("Vary", "Accept")
+			format = negotiateFeed(r.Header.Get("Accept"))
+		}
+		feed, err := generate(r, format)
 		if err != nil {
-			http.Error(w, err.Error(), 500)
+			switch {
+			case errors.Is(err, types.ErrNotFound):
+				http.NotFound(w, r)
+			case errors.Is(err, types.ErrBadRequest):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				http.Error(w, err.Error(), 500)
+			}
 			return
 		}
-		w.WriteHeader(200)
-		w.Write([]byte(feed))
+		// ServeContent answers conditional requests with 304 Not Modified
+		w.Header().Set("Content-Type", feed.ContentType+"; charset=utf-8")
+		w.Header().Set("ETag", feed.ETag)
+		w.Header().Set("Cache-Control", "no-cache")
+		// WebSub discovery, the same links are in the feed itself
+		if feed.Hub != "" {
+			w.Header().Add("Link", "<"+feed.Hub+`>; rel="hub"`)
+		}
+		if feed.Self != "" {
+			w.Header().Add("Link", "<"+feed.Self+`>; rel="self"`)
+		}
+		http.ServeContent(w, r, "", feed.Modified, strings.NewReader(feed.Body))
+	}
+}
+
+// feedMediaTypes are the media types each format is asked for with
+var feedMediaTypes = map[string]services.FeedFormat{
+	"application/atom+xml":  services.FeedAtom,
+	"application/rss+xml":   services.FeedRSS,
+	"application/feed+json": services.FeedJSON,
+	"application/json":      services.FeedJSON,
+	"application/xml":       services.FeedAtom,
+	"text/xml":              services.FeedAtom,
+}
+
+// negotiateFeed picks the format with the highest quality in accept. Ties
+// go to the type listed first.
+func negotiateFeed(accept string) services.FeedFormat {
+	best, bestQ := services.FeedFormats[0], 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+		format, ok := feedMediaTypes[strings.ToLower(strings.TrimSpace(mediaType))]
+		if !ok {
+			continue
+		}
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "q" {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > bestQ {
+			best, bestQ = format, q
+		}
 	}
+	return best
 }
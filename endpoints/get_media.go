@@ -0,0 +1,31 @@
+package endpoints
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func GetMedia(logger logging.Logger, service services.Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageS := r.URL.Query().Get("page")
+		if pageS == "" {
+			pageS = "1"
+		}
+		page, err := strconv.Atoi(pageS)
+		if err != nil || page < 1 {
+			utils.RenderBlock(w, "alert", "wrong page number")
+			return
+		}
+		media, err := service.GetMedia(r.Context(), page, 20)
+		if err != nil {
+			logger.Error(err.Error())
+			utils.RenderBlock(w, "alert_danger", "can't fetch media")
+			return
+		}
+		utils.RenderBlock(w, "media", media)
+	}
+}
@@ -5,13 +5,15 @@ import (
 
 	"github.com/yosa12978/echoes/logging"
 	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/session"
+	"github.com/yosa12978/echoes/types"
 	"github.com/yosa12978/echoes/utils"
 )
 
 func GetPostById(logger logging.Logger, service services.Post) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id := r.PathValue("id")
-		post, err := service.GetPostById(r.Context(), id)
+		post, err := readPost(r, service, id)
 		if err != nil {
 			logger.Error(err.Error())
 			utils.RenderBlock(w, "alert", "post not found")
@@ -20,3 +22,12 @@ func GetPostById(logger logging.Logger, service services.Post) http.HandlerFunc
 		utils.RenderBlock(w, "post", post)
 	}
 }
+
+// readPost finds the post id as the visitor of r may see it, drafts are
+// only shown to admins
+func readPost(r *http.Request, service services.Post, id string) (*types.Post, error) {
+	if session.IsAdmin(r) {
+		return service.GetPostById(r.Context(), id)
+	}
+	return service.GetPublishedPostById(r.Context(), id)
+}
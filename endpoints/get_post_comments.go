@@ -10,19 +10,27 @@ import (
 	"github.com/yosa12978/echoes/utils"
 )
 
+// GetPostComments pages with an opaque cursor unless page is passed
+// explicitly.
 func GetPostComments(logger logging.Logger, service services.Comment) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		postId := r.URL.Query().Get("postId") // temporary solution
 		pagestr := r.URL.Query().Get("page")
-		if pagestr == "" {
-			pagestr = "1"
+		cursor := r.URL.Query().Get("cursor")
+		var (
+			commentsPaged *types.Page[types.Comment]
+			err           error
+		)
+		if pagestr != "" {
+			page, perr := strconv.Atoi(pagestr)
+			if perr != nil {
+				utils.RenderBlock(w, "alert", "wrong page number")
+				return
+			}
+			commentsPaged, err = service.GetPostComments(r.Context(), postId, page, 20)
+		} else {
+			commentsPaged, err = service.GetPostCommentsAfter(r.Context(), postId, cursor, 20)
 		}
-		page, err := strconv.Atoi(pagestr)
-		if err != nil {
-			utils.RenderBlock(w, "alert", "wrong page number")
-			return
-		}
-		commentsPaged, err := service.GetPostComments(r.Context(), postId, page, 20)
 		if err != nil {
 			logger.Error(err.Error())
 			utils.RenderBlock(w, "alert", "can't fetch post comments")
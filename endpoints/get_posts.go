@@ -1,6 +1,7 @@
 package endpoints
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -10,9 +11,14 @@ import (
 	"github.com/yosa12978/echoes/utils"
 )
 
+// GetPosts pages with an opaque cursor by default. Passing page
+// explicitly (or searching) falls back to page-number pagination.
 func GetPosts(logger logging.Logger, service services.Post) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		pageS := r.URL.Query().Get("page")
+		cursor := r.URL.Query().Get("cursor")
+		searchQuery := r.URL.Query().Get("query")
+		usePages := pageS != "" || searchQuery != ""
 		if pageS == "" {
 			pageS = "1"
 		}
@@ -27,22 +33,27 @@ func GetPosts(logger logging.Logger, service services.Post) http.HandlerFunc {
 			limitS = "20"
 		}
 		limit, err := strconv.Atoi(limitS)
-		if err != nil {
-			logger.Error(err.Error())
+		if err != nil || limit < 1 {
 			utils.RenderBlock(w, "alert", "wrond limit number")
 			return
 		}
-		searchQuery := r.URL.Query().Get("query")
 		var posts *types.Page[types.Post]
-		if searchQuery == "" {
-			posts, err = service.GetPostsPaged(r.Context(), page, limit)
-		} else {
+		switch {
+		case searchQuery != "":
 			posts, err = service.Search(r.Context(), searchQuery, page, limit)
+		case usePages:
+			posts, err = service.GetPostsPaged(r.Context(), page, limit)
+		default:
+			posts, err = service.GetPostsAfter(r.Context(), cursor, limit)
 		}
 		if err != nil {
+			if errors.Is(err, types.ErrBadRequest) {
+				utils.RenderBlock(w, "alert", "wrong cursor")
+				return
+			}
 			logger.Error(err.Error())
 		}
-		if posts.Total == 0 {
+		if posts == nil || len(posts.Content) == 0 {
 			utils.RenderBlock(w, "noPosts", nil)
 			return
 		}
@@ -0,0 +1,49 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func GetSitemap(logger logging.Logger, service services.Sitemap) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sitemap, err := service.GenerateSitemap(r.Context(), utils.SiteURL(r, ""))
+		serveSitemap(w, r, logger, sitemap, err)
+	}
+}
+
+// GetSitemapPage serves the numbered sitemaps listed in the sitemap index,
+// the path value is like "2.xml"
+func GetSitemapPage(logger logging.Logger, service services.Sitemap) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		number, ok := strings.CutSuffix(r.PathValue("page"), ".xml")
+		page, err := strconv.Atoi(number)
+		if !ok || err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		sitemap, err := service.GenerateSitemapPage(r.Context(), utils.SiteURL(r, ""), page)
+		serveSitemap(w, r, logger, sitemap, err)
+	}
+}
+
+func serveSitemap(w http.ResponseWriter, r *http.Request, logger logging.Logger, sitemap *types.Sitemap, err error) {
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		logger.Error(err.Error())
+		http.Error(w, "failed to generate the sitemap", 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	http.ServeContent(w, r, "", sitemap.Modified, strings.NewReader(sitemap.Body))
+}
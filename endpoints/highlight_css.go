@@ -0,0 +1,30 @@
+package endpoints
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"github.com/yosa12978/echoes/markdown"
+)
+
+// HighlightCSS serves the stylesheet for highlighted code blocks. It only
+// depends on the config, so it's generated once.
+func HighlightCSS(renderer *markdown.Renderer) http.HandlerFunc {
+	var (
+		css  bytes.Buffer
+		err  error
+		once sync.Once
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			err = renderer.WriteCSS(&css)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		w.Write(css.Bytes())
+	}
+}
@@ -0,0 +1,45 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func ImportBackup(logger logging.Logger, service services.Backup) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			utils.RenderBlock(w, "alert_danger", "Failed to read upload")
+			return
+		}
+		file, header, err := r.FormFile("archive")
+		if err != nil {
+			utils.RenderBlock(w, "alert_danger", "Choose a backup archive")
+			return
+		}
+		defer file.Close()
+
+		mode := r.FormValue("mode")
+		if mode == "" {
+			mode = types.ImportMerge
+		}
+		report, err := service.Import(r.Context(), file, header.Size, mode)
+		if err != nil {
+			if errors.Is(err, types.ErrBadRequest) {
+				utils.RenderBlock(w, "alert_danger", err.Error())
+				return
+			}
+			logger.Error(err.Error())
+			utils.RenderBlock(w, "alert_danger", "Failed to import backup")
+			return
+		}
+		for _, e := range report.Errors {
+			logger.Warn("backup import", "error", e)
+		}
+		utils.RenderBlock(w, "alert_success", report.String())
+	}
+}
@@ -0,0 +1,34 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yosa12978/echoes/openapi"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+// OpenAPI serves the OpenAPI document of the server. It doesn't change
+// while the server runs, so it's encoded once.
+func OpenAPI(doc *openapi.Document) http.HandlerFunc {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, err.Error(), 500)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}
+}
+
+// APIDocs renders the OpenAPI document as a page, without loading anything
+// from outside the site
+func APIDocs(doc *openapi.Document) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := utils.RenderView(w, "apidocs", "api", types.Meta{}, doc); err != nil {
+			http.Error(w, err.Error(), 500)
+		}
+	}
+}
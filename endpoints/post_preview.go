@@ -0,0 +1,33 @@
+package endpoints
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// PostPreview serves the generated social preview image of a post. The
+// version of the image is its ETag, so crawlers can revalidate cheaply.
+func PostPreview(logger logging.Logger, service services.Preview) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		image, version, err := service.PostPreview(r.Context(), r.PathValue("id"))
+		if err != nil {
+			if errors.Is(err, types.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			logger.Error(err.Error())
+			http.Error(w, "failed to draw the preview", 500)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("ETag", `"`+version+`"`)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(image))
+	}
+}
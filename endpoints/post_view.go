@@ -0,0 +1,39 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+// PostView renders the page of a post with the post in it, so link
+// previews and crawlers see it without running scripts. Comments are still
+// loaded with htmx. Drafts are only shown to admins.
+func PostView(logger logging.Logger, service services.Post) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		post, err := readPost(r, service, id)
+		if err != nil {
+			if !errors.Is(err, types.ErrNotFound) {
+				logger.Error(err.Error())
+				http.Error(w, "failed to load the post", 500)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusNotFound)
+			if err := utils.RenderView(w, "err404", "Error 404", types.Meta{}, nil); err != nil {
+				http.Error(w, err.Error(), 500)
+			}
+			return
+		}
+		meta := utils.PostMeta(*post, func(path string) string { return utils.SiteURL(r, path) })
+		view := types.PostView{Id: post.Id, Post: post}
+		if err := utils.RenderView(w, "post", "blog - "+post.Title, meta, view); err != nil {
+			http.Error(w, err.Error(), 500)
+		}
+	}
+}
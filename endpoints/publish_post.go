@@ -0,0 +1,35 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func PublishPost(logger logging.Logger, service services.Post) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Id string `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		_, err := service.PublishPost(r.Context(), body.Id)
+		if err != nil {
+			switch {
+			case errors.Is(err, types.ErrNotFound):
+				utils.RenderBlock(w, "alert_danger", "Post not found")
+			case errors.Is(err, types.ErrBadRequest):
+				utils.RenderBlock(w, "alert_danger", "Post is already published")
+			default:
+				logger.Error(err.Error())
+				utils.RenderBlock(w, "alert_danger", "Failed to publish post")
+			}
+			return
+		}
+		utils.RenderBlock(w, "alert_success", "Post published")
+	}
+}
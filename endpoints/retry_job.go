@@ -0,0 +1,20 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/jobs"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func RetryJob(logger logging.Logger, queue jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := queue.Retry(r.Context(), r.PathValue("id")); err != nil {
+			logger.Error(err.Error())
+			utils.RenderBlock(w, "alert_danger", "Failed to retry job")
+			return
+		}
+		utils.RenderBlock(w, "alert_success", "Job queued for retry")
+	}
+}
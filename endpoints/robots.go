@@ -0,0 +1,25 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/utils"
+)
+
+// Robots serves website.robots, or rules keeping crawlers off the admin
+// pages and pointing them at the sitemap
+func Robots() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if robots := config.Get().Website.Robots; robots != "" {
+			w.Write([]byte(robots))
+			return
+		}
+		w.Write([]byte("User-agent: *\n" +
+			"Disallow: /admin\n" +
+			"Disallow: /login\n" +
+			"\n" +
+			"Sitemap: " + utils.SiteURL(r, "/sitemap.xml") + "\n"))
+	}
+}
@@ -0,0 +1,38 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// ServeMedia serves uploaded files. Keys are never reused, so files can be
+// cached for good.
+func ServeMedia(logger logging.Logger, service services.Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		obj, err := service.Open(r.Context(), r.PathValue("key"))
+		if err != nil {
+			if errors.Is(err, types.ErrNotFound) || errors.Is(err, types.ErrBadRequest) {
+				http.NotFound(w, r)
+				return
+			}
+			logger.Error(err.Error())
+			http.Error(w, "failed to read file", http.StatusInternalServerError)
+			return
+		}
+		defer obj.Close()
+
+		h := w.Header()
+		if obj.ContentType != "" {
+			h.Set("Content-Type", obj.ContentType)
+		}
+		h.Set("Cache-Control", "public, max-age=31536000, immutable")
+		h.Set("X-Content-Type-Options", "nosniff")
+		// uploads are never pages of the site
+		h.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; sandbox")
+		http.ServeContent(w, r, "", obj.Modified, obj)
+	}
+}
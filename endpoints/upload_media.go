@@ -0,0 +1,48 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+func UploadMedia(logger logging.Logger, service services.Media) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// room for the multipart headers around the file
+		r.Body = http.MaxBytesReader(w, r.Body, service.MaxSize()+1<<20)
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.RenderBlock(w, "alert_danger", "File is too large")
+				return
+			}
+			utils.RenderBlock(w, "alert_danger", "Failed to read upload")
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			utils.RenderBlock(w, "alert_danger", "Choose a file")
+			return
+		}
+		defer file.Close()
+
+		media, err := service.Upload(r.Context(), header.Filename, file, header.Size)
+		if err != nil {
+			if errors.Is(err, types.ErrBadRequest) {
+				utils.RenderBlock(w, "alert_danger", err.Error())
+				return
+			}
+			logger.Error(err.Error())
+			utils.RenderBlock(w, "alert_danger", "Failed to upload")
+			return
+		}
+		// lets the media library reload
+		w.Header().Set("HX-Trigger", "media-uploaded")
+		utils.RenderBlock(w, "media_uploaded", media)
+	}
+}
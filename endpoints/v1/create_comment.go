@@ -0,0 +1,31 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+func CreateComment(logger logging.Logger, posts services.Post, comments services.Comment) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dto, ok := readDto[types.CommentCreateDto](w, r)
+		if !ok {
+			return
+		}
+		post, err := posts.GetPublishedPostById(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		comment, err := comments.CreateComment(r.Context(), post.Id, dto.Name, dto.Email, dto.Content)
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/comments/"+url.PathEscape(comment.Id))
+		writeData(w, http.StatusCreated, types.NewAPIComment(*comment))
+	}
+}
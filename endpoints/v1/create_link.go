@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+func CreateLink(logger logging.Logger, service services.Link) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dto, ok := readDto[types.LinkCreateDto](w, r)
+		if !ok {
+			return
+		}
+		place, err := strconv.Atoi(dto.Place)
+		if err != nil {
+			writeProblems(w, map[string]string{"place": "place must be a number"})
+			return
+		}
+		link, err := service.CreateLink(r.Context(), dto.Name, dto.URL, dto.Icon, place)
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/links/"+url.PathEscape(link.Id))
+		writeData(w, http.StatusCreated, types.NewAPILink(*link))
+	}
+}
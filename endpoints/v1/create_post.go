@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// CreatePost takes the same body as the admin form, so tweet and toc are
+// turned on by any non empty string
+func CreatePost(logger logging.Logger, service services.Post) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dto, ok := readDto[types.PostCreateDto](w, r)
+		if !ok {
+			return
+		}
+		created, err := service.CreatePost(r.Context(), dto.Title, dto.Content, dto.Tweet != "", dto.TOC != "")
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		// created posts aren't rendered yet
+		post, err := service.GetPostById(r.Context(), created.Id)
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/posts/"+url.PathEscape(post.Id))
+		writeData(w, http.StatusCreated, types.NewAPIPost(*post))
+	}
+}
@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+)
+
+func DeleteAnnounce(logger logging.Logger, service services.Announce) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := service.Delete(r.Context()); err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
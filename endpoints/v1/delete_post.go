@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+)
+
+func DeletePost(logger logging.Logger, service services.Post) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, err := service.DeletePost(r.Context(), r.PathValue("id")); err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
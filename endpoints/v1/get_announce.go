@@ -0,0 +1,23 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+func GetAnnounce(logger logging.Logger, service services.Announce) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		announce, err := service.Get(r.Context())
+		if err == nil && announce == nil {
+			err = types.ErrNotFound
+		}
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		writeData(w, http.StatusOK, types.NewAPIAnnounce(*announce))
+	}
+}
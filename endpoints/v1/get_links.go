@@ -0,0 +1,26 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// GetLinks lists every link in the order of their place, links aren't
+// paged
+func GetLinks(logger logging.Logger, service services.Link) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		links, err := service.GetLinks(r.Context())
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		res := make([]types.APILink, len(links))
+		for i, link := range links {
+			res[i] = types.NewAPILink(link)
+		}
+		writeData(w, http.StatusOK, res)
+	}
+}
@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+func GetPost(logger logging.Logger, service services.Post) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		post, err := service.GetPublishedPostById(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		writeData(w, http.StatusOK, types.NewAPIPost(*post))
+	}
+}
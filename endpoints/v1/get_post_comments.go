@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// GetPostComments lists the comments of a post newest first. It follows a
+// cursor unless page is set.
+func GetPostComments(logger logging.Logger, posts services.Post, comments services.Comment) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, limit, err := pageQuery(r)
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		// a post without comments and a missing post both have no comments
+		post, err := posts.GetPublishedPostById(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		var res *types.Page[types.Comment]
+		if page != 0 {
+			res, err = comments.GetPostComments(r.Context(), post.Id, page, limit)
+		} else {
+			res, err = comments.GetPostCommentsAfter(r.Context(), post.Id, r.URL.Query().Get("cursor"), limit)
+		}
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		writePage(w, res, types.NewAPIComment)
+	}
+}
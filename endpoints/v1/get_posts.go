@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// GetPosts lists posts newest first, pinned ones on top. It follows a
+// cursor by default, while page, query and tag use numbered pages.
+func GetPosts(logger logging.Logger, service services.Post) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, limit, err := pageQuery(r)
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		query := r.URL.Query()
+		if page == 0 && (query.Has("query") || query.Has("tag")) {
+			page = 1
+		}
+		var posts *types.Page[types.Post]
+		switch {
+		case query.Get("query") != "":
+			posts, err = service.Search(r.Context(), query.Get("query"), page, limit)
+		case query.Has("tag"):
+			posts, err = service.GetPostsByTag(r.Context(), query.Get("tag"), page, limit)
+		case page != 0:
+			posts, err = service.GetPostsPaged(r.Context(), page, limit)
+		default:
+			posts, err = service.GetPostsAfter(r.Context(), query.Get("cursor"), limit)
+		}
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		writePage(w, posts, types.NewAPIPost)
+	}
+}
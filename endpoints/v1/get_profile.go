@@ -0,0 +1,19 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+)
+
+func GetProfile(logger logging.Logger, service services.Profile) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		profile, err := service.Get(r.Context())
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		writeData(w, http.StatusOK, *profile)
+	}
+}
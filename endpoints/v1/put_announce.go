@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// PutAnnounce replaces the announcement, there is only ever one
+func PutAnnounce(logger logging.Logger, service services.Announce) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dto, ok := readDto[types.AnnounceCreateDto](w, r)
+		if !ok {
+			return
+		}
+		if err := service.Create(r.Context(), dto.Content); err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		announce, err := service.Get(r.Context())
+		if err != nil {
+			writeError(w, logger, err)
+			return
+		}
+		writeData(w, http.StatusOK, types.NewAPIAnnounce(*announce))
+	}
+}
@@ -0,0 +1,111 @@
+// Package v1 serves the JSON API at /api/v1. Successful responses wrap
+// their resource in "data", failed ones carry an "error" envelope.
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+	"github.com/yosa12978/echoes/validation"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	// maxBody is plenty for a post
+	maxBody = 1 << 20
+)
+
+func writeData[T any](w http.ResponseWriter, status int, data T) {
+	utils.WriteJson(w, types.APIData[T]{Data: data}, status)
+}
+
+func writePage[T, R any](w http.ResponseWriter, page *types.Page[T], convert func(T) R) {
+	utils.WriteJson(w, types.NewAPIPage(page, convert), http.StatusOK)
+}
+
+// writeError maps err to a status code. The messages of internal failures
+// are logged and not shown.
+func writeError(w http.ResponseWriter, logger logging.Logger, err error) {
+	switch {
+	case errors.Is(err, types.ErrNotFound):
+		utils.WriteAPIError(w, http.StatusNotFound, "not_found", "not found")
+	case errors.Is(err, types.ErrBadRequest):
+		utils.WriteAPIError(w, http.StatusBadRequest, "bad_request", message(err, types.ErrBadRequest))
+	case errors.Is(err, types.ErrValidationFailed):
+		utils.WriteAPIError(w, http.StatusUnprocessableEntity, "validation_failed", message(err, types.ErrValidationFailed))
+	default:
+		logger.Error(err.Error())
+		utils.WriteAPIError(w, http.StatusInternalServerError, "internal", "internal failure")
+	}
+}
+
+// message is the first error joined with kind by types.NewErr*, the one that
+// says what happened
+func message(err, kind error) string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			if e != kind {
+				return e.Error()
+			}
+		}
+	}
+	return err.Error()
+}
+
+func writeProblems(w http.ResponseWriter, problems map[string]string) {
+	utils.WriteJson(w, types.APIError{Error: types.APIErrorBody{
+		Code:     "validation_failed",
+		Message:  "the request has invalid fields",
+		Problems: problems,
+	}}, http.StatusUnprocessableEntity)
+}
+
+// readDto decodes and validates the body of r. When it fails the response
+// is already written.
+func readDto[T validation.Validatable[T]](w http.ResponseWriter, r *http.Request) (T, bool) {
+	dto, problems, err := utils.ReadJsonAndValidate[T](r.Context(), http.MaxBytesReader(w, r.Body, maxBody))
+	switch {
+	case errors.Is(err, types.ErrValidationFailed):
+		writeProblems(w, problems)
+		return dto, false
+	case err != nil:
+		utils.WriteAPIError(w, http.StatusBadRequest, "bad_request", "the body isn't valid json: "+err.Error())
+		return dto, false
+	}
+	return dto, true
+}
+
+// pageQuery reads the page and limit query parameters. Page is 0 when it
+// isn't set.
+func pageQuery(r *http.Request) (page, limit int, err error) {
+	query := r.URL.Query()
+	if s := query.Get("page"); s != "" {
+		if page, err = strconv.Atoi(s); err != nil || page < 1 {
+			return 0, 0, types.NewErrBadRequest(errors.New("page must be a positive number"))
+		}
+	}
+	limit = defaultLimit
+	if s := query.Get("limit"); s != "" {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, types.NewErrBadRequest(fmt.Errorf("limit must be a number from 1 to %d", maxLimit))
+		}
+	}
+	return page, limit, nil
+}
+
+// NotFound answers paths of the API that don't exist
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	utils.WriteAPIError(w, http.StatusNotFound, "not_found", "no such endpoint")
+}
+
+// MethodNotAllowed answers paths of the API requested with a method they
+// aren't served under, the Allow header is already set
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	utils.WriteAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+}
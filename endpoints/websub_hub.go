@@ -0,0 +1,51 @@
+package endpoints
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// WebSubHub takes form encoded subscription requests for the built-in hub.
+// They are accepted before the subscriber confirms them.
+func WebSubHub(logger logging.Logger, service services.WebSub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "failed to read the request", http.StatusBadRequest)
+			return
+		}
+		req := types.SubscriptionRequest{
+			Mode:     r.PostForm.Get("hub.mode"),
+			Callback: r.PostForm.Get("hub.callback"),
+			Topic:    r.PostForm.Get("hub.topic"),
+			Secret:   r.PostForm.Get("hub.secret"),
+		}
+		if lease := r.PostForm.Get("hub.lease_seconds"); lease != "" {
+			seconds, err := strconv.Atoi(lease)
+			if err != nil {
+				http.Error(w, "hub.lease_seconds isn't a number", http.StatusBadRequest)
+				return
+			}
+			req.Lease = seconds
+		}
+
+		if err := service.Subscribe(r.Context(), req); err != nil {
+			switch {
+			case errors.Is(err, types.ErrNotFound):
+				http.NotFound(w, r)
+			case errors.Is(err, types.ErrBadRequest):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			default:
+				logger.Error(err.Error())
+				http.Error(w, "failed to take the subscription", 500)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
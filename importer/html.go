@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	extraNewlines = regexp.MustCompile(`\n{3,}`)
+	spaces        = regexp.MustCompile(`[ \t]+`)
+	shortcodes    = regexp.MustCompile(`\[/?caption[^\]]*\]`)
+	mdEscaper     = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+)
+
+// htmlToMarkdown converts the subset of HTML produced by WordPress editors
+// to Markdown. Elements without a Markdown counterpart, like tables, are
+// kept as raw HTML.
+func htmlToMarkdown(s string) string {
+	s = shortcodes.ReplaceAllString(s, "")
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return s
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		writeNode(&b, n)
+	}
+	md := extraNewlines.ReplaceAllString(b.String(), "\n\n")
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func writeChildren(b *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeNode(b, c)
+	}
+}
+
+func renderChildren(n *html.Node) string {
+	var b strings.Builder
+	writeChildren(&b, n)
+	return b.String()
+}
+
+func block(b *strings.Builder) {
+	s := b.String()
+	if s != "" && !strings.HasSuffix(s, "\n\n") {
+		if strings.HasSuffix(s, "\n") {
+			b.WriteString("\n")
+		} else {
+			b.WriteString("\n\n")
+		}
+	}
+}
+
+func writeNode(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(mdEscaper.Replace(spaces.ReplaceAllString(n.Data, " ")))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style:
+	case atom.P, atom.Div, atom.Figure:
+		block(b)
+		writeChildren(b, n)
+		block(b)
+	case atom.Br:
+		b.WriteString("  \n")
+	case atom.Hr:
+		block(b)
+		b.WriteString("---")
+		block(b)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		block(b)
+		level := int(n.Data[1] - '0')
+		b.WriteString(strings.Repeat("#", level) + " ")
+		b.WriteString(strings.TrimSpace(strings.ReplaceAll(renderChildren(n), "\n", " ")))
+		block(b)
+	case atom.Strong, atom.B:
+		writeWrapped(b, n, "**")
+	case atom.Em, atom.I:
+		writeWrapped(b, n, "*")
+	case atom.Del, atom.S:
+		writeWrapped(b, n, "~~")
+	case atom.Code:
+		b.WriteString("`" + textContent(n) + "`")
+	case atom.Pre:
+		block(b)
+		b.WriteString("```" + codeLanguage(n) + "\n")
+		b.WriteString(strings.Trim(textContent(n), "\n"))
+		b.WriteString("\n```")
+		block(b)
+	case atom.A:
+		href := attr(n, "href")
+		text := strings.TrimSpace(renderChildren(n))
+		switch {
+		case href == "":
+			b.WriteString(text)
+		case text == "":
+			b.WriteString("<" + href + ">")
+		default:
+			b.WriteString("[" + text + "](" + href + ")")
+		}
+	case atom.Img:
+		b.WriteString("![" + attr(n, "alt") + "](" + attr(n, "src") + ")")
+	case atom.Figcaption:
+		block(b)
+		b.WriteString("*" + strings.TrimSpace(renderChildren(n)) + "*")
+		block(b)
+	case atom.Blockquote:
+		block(b)
+		inner := strings.TrimSpace(extraNewlines.ReplaceAllString(renderChildren(n), "\n\n"))
+		for i, line := range strings.Split(inner, "\n") {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(strings.TrimRight("> "+line, " "))
+		}
+		block(b)
+	case atom.Ul, atom.Ol:
+		block(b)
+		writeList(b, n)
+		block(b)
+	case atom.Table:
+		block(b)
+		html.Render(b, n)
+		block(b)
+	default:
+		writeChildren(b, n)
+	}
+}
+
+func writeWrapped(b *strings.Builder, n *html.Node, mark string) {
+	text := renderChildren(n)
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		b.WriteString(text)
+		return
+	}
+	// markers can't be separated from the text by spaces
+	if strings.HasPrefix(text, " ") {
+		b.WriteString(" ")
+	}
+	b.WriteString(mark + trimmed + mark)
+	if strings.HasSuffix(text, " ") {
+		b.WriteString(" ")
+	}
+}
+
+func writeList(b *strings.Builder, n *html.Node) {
+	num := 1
+	first := true
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = strconv.Itoa(num) + ". "
+			num++
+		}
+		item := strings.TrimSpace(extraNewlines.ReplaceAllString(renderChildren(c), "\n\n"))
+		indent := strings.Repeat(" ", len(marker))
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+		for i, line := range strings.Split(item, "\n") {
+			switch {
+			case i == 0:
+				b.WriteString(marker + line)
+			case line == "":
+				b.WriteString("\n")
+			default:
+				b.WriteString("\n" + indent + line)
+			}
+		}
+	}
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// codeLanguage reads the language from class="language-go" on <pre> or on
+// the <code> inside it
+func codeLanguage(n *html.Node) string {
+	for _, node := range []*html.Node{n, n.FirstChild} {
+		if node == nil || node.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(node, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,210 @@
+// Package importer brings posts from other blogging tools into echoes.
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+	"gopkg.in/yaml.v3"
+)
+
+type Result struct {
+	File string
+	Post *types.Post
+	Err  error
+}
+
+type Report struct {
+	DryRun        bool
+	Results       []Result
+	Comments      int
+	CommentErrors []CommentError
+	Redirects     []Redirect
+}
+
+// CommentError is a comment that failed to import, the post it belongs to
+// is imported anyway
+type CommentError struct {
+	File    string
+	Author  string
+	Created string
+	Err     error
+}
+
+func (r Report) Imported() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (r Report) Failed() int {
+	return len(r.Results) - r.Imported()
+}
+
+// frontMatter holds the fields understood in Hugo and Jekyll front matter.
+// Date and tags are loosely typed because both tools accept several forms.
+type frontMatter struct {
+	Title     string `yaml:"title"`
+	Date      any    `yaml:"date"`
+	Draft     bool   `yaml:"draft"`
+	Published *bool  `yaml:"published"`
+	Tags      any    `yaml:"tags"`
+	Pinned    bool   `yaml:"pinned"`
+	TOC       bool   `yaml:"toc"`
+}
+
+var (
+	datePrefix  = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-`)
+	dateLayouts = []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05 -07:00",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+)
+
+// Markdown imports every .md and .markdown file under src, a directory or an
+// archive. A failing file doesn't stop the import, its error is recorded in
+// the report instead. With dryRun the files are only parsed.
+func Markdown(ctx context.Context, posts services.Post, src string, dryRun bool) (*Report, error) {
+	report := &Report{DryRun: dryRun}
+	err := walk(src, []string{".md", ".markdown"}, func(name string, data []byte) {
+		if path.Base(name) == "_index.md" {
+			return
+		}
+		post, err := ParseMarkdown(name, data)
+		if err == nil && !dryRun {
+			post, err = posts.ImportPost(ctx, *post)
+		}
+		report.Results = append(report.Results, Result{File: name, Post: post, Err: err})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// ParseMarkdown turns a Markdown file with optional YAML front matter into a
+// post. Missing titles and dates are taken from Jekyll style file names
+// like 2016-05-20-my-post.md.
+func ParseMarkdown(name string, data []byte) (*types.Post, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	var fm frontMatter
+	body := data
+	switch {
+	case bytes.HasPrefix(data, []byte("+++\n")):
+		return nil, errors.New("TOML front matter isn't supported, convert it to YAML")
+	case bytes.HasPrefix(data, []byte("---\n")):
+		rest := data[4:]
+		// end is where the front matter stops and closing where the
+		// closing --- starts, they're the same when the front matter is empty
+		end, closing := 0, 0
+		if !bytes.HasPrefix(rest, []byte("---")) {
+			end = bytes.Index(rest, []byte("\n---"))
+			if end < 0 {
+				return nil, errors.New("front matter isn't closed with ---")
+			}
+			closing = end + 1
+		}
+		if err := yaml.Unmarshal(rest[:end], &fm); err != nil {
+			return nil, fmt.Errorf("invalid front matter: %w", err)
+		}
+		body = rest[closing+3:]
+		if i := bytes.IndexByte(body, '\n'); i >= 0 {
+			body = body[i+1:]
+		} else {
+			body = nil
+		}
+	}
+
+	content := strings.TrimSpace(string(body))
+	if content == "" {
+		return nil, errors.New("post has no content")
+	}
+
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	if base == "index" {
+		// hugo page bundles keep the post in <slug>/index.md
+		base = path.Base(path.Dir(name))
+	}
+	created, err := parseDate(fm.Date, base)
+	if err != nil {
+		return nil, err
+	}
+	title := strings.TrimSpace(fm.Title)
+	if title == "" {
+		title = strings.ReplaceAll(datePrefix.ReplaceAllString(base, ""), "-", " ")
+	}
+	tags, err := parseTags(fm.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Post{
+		Title:   title,
+		Content: content,
+		Created: created.UTC().Format(time.RFC3339),
+		Pinned:  fm.Pinned,
+		Tags:    tags,
+		Draft:   fm.Draft || (fm.Published != nil && !*fm.Published),
+		TOC:     fm.TOC,
+	}, nil
+}
+
+func parseDate(value any, base string) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognized date %q", v)
+	case nil:
+		if m := datePrefix.FindStringSubmatch(base); m != nil {
+			return time.Parse("2006-01-02", m[1])
+		}
+		return time.Time{}, errors.New("post has no date")
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %v", value)
+}
+
+// parseTags accepts a list or a string separated by commas or, like Jekyll
+// does, by spaces
+func parseTags(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if strings.Contains(v, ",") {
+			return types.NormalizeTags(strings.Split(v, ",")), nil
+		}
+		return types.NormalizeTags(strings.Fields(v)), nil
+	case []any:
+		tags := make([]string, 0, len(v))
+		for _, tag := range v {
+			tags = append(tags, fmt.Sprint(tag))
+		}
+		return types.NormalizeTags(tags), nil
+	}
+	return nil, fmt.Errorf("unrecognized tags %v", value)
+}
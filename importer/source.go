@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// walk calls fn for every regular file under src whose extension is one of
+// exts. src may be a directory, a .zip archive or a .tar, .tar.gz or .tgz
+// archive.
+func walk(src string, exts []string, fn func(name string, data []byte)) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	match := func(name string) bool {
+		return slices.Contains(exts, strings.ToLower(path.Ext(name)))
+	}
+	if info.IsDir() {
+		return walkFS(os.DirFS(src), match, fn)
+	}
+
+	lower := strings.ToLower(src)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		zr, err := zip.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return walkFS(zr, match, fn)
+	case strings.HasSuffix(lower, ".tar"),
+		strings.HasSuffix(lower, ".tar.gz"),
+		strings.HasSuffix(lower, ".tgz"):
+		f, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		var r io.Reader = f
+		if !strings.HasSuffix(lower, ".tar") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			r = gz
+		}
+		return walkTar(tar.NewReader(r), match, fn)
+	}
+	return errors.New("source must be a directory or a .zip, .tar, .tar.gz or .tgz archive")
+}
+
+func walkFS(fsys fs.FS, match func(string) bool, fn func(name string, data []byte)) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !match(name) {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		fn(name, data)
+		return nil
+	})
+}
+
+func walkTar(tr *tar.Reader, match func(string) bool, fn func(name string, data []byte)) error {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg || !match(hdr.Name) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		fn(hdr.Name, data)
+	}
+}
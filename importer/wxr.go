@@ -0,0 +1,226 @@
+package importer
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// Redirect maps the address of a post on the old site to its new address
+type Redirect struct {
+	Old string
+	New string
+}
+
+type wxrFile struct {
+	Items []wxrItem `xml:"channel>item"`
+}
+
+type wxrItem struct {
+	Title    string       `xml:"title"`
+	Link     string       `xml:"link"`
+	PubDate  string       `xml:"pubDate"`
+	Content  string       `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	DateGMT  string       `xml:"post_date_gmt"`
+	Date     string       `xml:"post_date"`
+	Status   string       `xml:"status"`
+	PostType string       `xml:"post_type"`
+	Sticky   int          `xml:"is_sticky"`
+	Terms    []wxrTerm    `xml:"category"`
+	Comments []wxrComment `xml:"comment"`
+}
+
+type wxrTerm struct {
+	Domain string `xml:"domain,attr"`
+	Name   string `xml:",chardata"`
+}
+
+type wxrComment struct {
+	Id       string `xml:"comment_id"`
+	Author   string `xml:"comment_author"`
+	Email    string `xml:"comment_author_email"`
+	DateGMT  string `xml:"comment_date_gmt"`
+	Date     string `xml:"comment_date"`
+	Content  string `xml:"comment_content"`
+	Approved string `xml:"comment_approved"`
+	Type     string `xml:"comment_type"`
+	Parent   string `xml:"comment_parent"`
+}
+
+// wordpress writes this instead of a date for posts that were never published
+const zeroDate = "0000-00-00 00:00:00"
+
+// WXR imports the posts and approved comments of a WordPress export file.
+// Post links are resolved against postURL, the address posts are served
+// under followed by their id. With dryRun the file is only parsed.
+func WXR(ctx context.Context, posts services.Post, comments services.Comment, src, postURL string, dryRun bool) (*Report, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var file wxrFile
+	if err := xml.NewDecoder(f).Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid WXR file: %w", err)
+	}
+
+	report := &Report{DryRun: dryRun}
+	for _, item := range file.Items {
+		if item.PostType != "post" || item.Status == "trash" {
+			continue
+		}
+		post, postComments, err := parseWXRItem(item)
+		if err == nil && !dryRun {
+			post, err = posts.ImportPost(ctx, *post)
+		}
+		name := item.Link
+		if name == "" {
+			name = item.Title
+		}
+		report.Results = append(report.Results, Result{File: name, Post: post, Err: err})
+		if err != nil {
+			continue
+		}
+		if item.Link != "" && !post.Draft {
+			report.Redirects = append(report.Redirects, Redirect{Old: item.Link, New: postURL + post.Id})
+		}
+		for _, comment := range postComments {
+			comment.PostId = post.Id
+			if !dryRun {
+				if _, err := comments.ImportComment(ctx, comment); err != nil {
+					report.CommentErrors = append(report.CommentErrors, CommentError{
+						File:    name,
+						Author:  comment.Name,
+						Created: comment.Created,
+						Err:     err,
+					})
+					continue
+				}
+			}
+			report.Comments++
+		}
+	}
+	return report, nil
+}
+
+func parseWXRItem(item wxrItem) (*types.Post, []types.Comment, error) {
+	content := htmlToMarkdown(item.Content)
+	if content == "" {
+		return nil, nil, errors.New("post has no content")
+	}
+	draft := item.Status == "draft" || item.Status == "pending" || item.Status == "private"
+	created, err := wxrDate(item.DateGMT, item.Date, item.PubDate)
+	if err != nil && !draft {
+		return nil, nil, err
+	}
+	if err != nil {
+		// drafts that were never saved with a date
+		created = time.Now()
+	}
+	title := strings.TrimSpace(item.Title)
+	if title == "" {
+		title = created.Format("2006-01-02")
+	}
+	var tags []string
+	for _, term := range item.Terms {
+		if term.Domain == "post_tag" {
+			tags = append(tags, term.Name)
+		}
+	}
+	post := &types.Post{
+		Id:      uuid.NewString(),
+		Title:   title,
+		Content: content,
+		Created: created.UTC().Format(time.RFC3339),
+		Pinned:  item.Sticky == 1,
+		Tags:    types.NormalizeTags(tags),
+		Draft:   draft,
+	}
+	return post, wxrComments(item.Comments), nil
+}
+
+// wxrComments keeps approved comments in the order they were written.
+// Echoes comments aren't threaded, so replies mention who they answer.
+func wxrComments(items []wxrComment) []types.Comment {
+	authors := make(map[string]string, len(items))
+	for _, c := range items {
+		authors[c.Id] = strings.TrimSpace(c.Author)
+	}
+
+	type dated struct {
+		created time.Time
+		comment types.Comment
+	}
+	var approved []dated
+	for _, c := range items {
+		if c.Approved != "1" || (c.Type != "" && c.Type != "comment") {
+			continue
+		}
+		created, err := wxrDate(c.DateGMT, c.Date, "")
+		if err != nil {
+			continue
+		}
+		content := htmlToMarkdown(c.Content)
+		if content == "" {
+			continue
+		}
+		if parent := authors[c.Parent]; c.Parent != "0" && parent != "" {
+			content = "@" + parent + " " + content
+		}
+		name := strings.TrimSpace(c.Author)
+		if name == "" {
+			name = "Anonymous"
+		}
+		approved = append(approved, dated{
+			created: created,
+			comment: types.Comment{
+				Id:      uuid.NewString(),
+				Name:    name,
+				Email:   strings.TrimSpace(c.Email),
+				Content: content,
+				Created: created.UTC().Format(time.RFC3339),
+			},
+		})
+	}
+	slices.SortStableFunc(approved, func(a, b dated) int {
+		return a.created.Compare(b.created)
+	})
+
+	comments := make([]types.Comment, len(approved))
+	for i, c := range approved {
+		comments[i] = c.comment
+	}
+	return comments
+}
+
+// wxrDate prefers the GMT date, falling back to the local date and then to
+// the RSS pubDate
+func wxrDate(gmt, local, pubDate string) (time.Time, error) {
+	for _, value := range []string{gmt, local} {
+		value = strings.TrimSpace(value)
+		if value == "" || value == zeroDate {
+			continue
+		}
+		if t, err := time.Parse(time.DateTime, value); err == nil {
+			return t, nil
+		}
+	}
+	if pubDate = strings.TrimSpace(pubDate); pubDate != "" {
+		for _, layout := range []string{time.RFC1123Z, time.RFC1123} {
+			if t, err := time.Parse(layout, pubDate); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, errors.New("post has no date")
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import "time"
+
+type optionFunc func(*options)
+
+type options struct {
+	workers      int
+	pollInterval time.Duration
+	lease        time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+}
+
+func defaultOptions() options {
+	return options{
+		workers:      2,
+		pollInterval: time.Second,
+		lease:        time.Minute,
+		maxAttempts:  5,
+		backoff:      10 * time.Second,
+		maxBackoff:   time.Hour,
+	}
+}
+
+func newOptions(opts ...optionFunc) options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func WithWorkers(n int) optionFunc {
+	return func(o *options) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+func WithPollInterval(d time.Duration) optionFunc {
+	return func(o *options) {
+		if d > 0 {
+			o.pollInterval = d
+		}
+	}
+}
+
+// WithLease sets how long a claimed job stays locked. It also bounds the
+// run time of a single attempt.
+func WithLease(d time.Duration) optionFunc {
+	return func(o *options) {
+		if d > 0 {
+			o.lease = d
+		}
+	}
+}
+
+func WithMaxAttempts(n int) optionFunc {
+	return func(o *options) {
+		if n > 0 {
+			o.maxAttempts = n
+		}
+	}
+}
+
+func WithBackoff(base, max time.Duration) optionFunc {
+	return func(o *options) {
+		if base > 0 {
+			o.backoff = base
+		}
+		if max > 0 {
+			o.maxBackoff = max
+		}
+	}
+}
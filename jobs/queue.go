@@ -0,0 +1,243 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/types"
+)
+
+// Handler processes a single job. Returning an error schedules a retry with
+// exponential backoff until the job runs out of attempts and is dead-lettered.
+type Handler func(ctx context.Context, job types.Job) error
+
+type Queue interface {
+	Enqueue(ctx context.Context, kind string, payload any, opts ...EnqueueOption) (*types.Job, error)
+	Handle(kind string, handler Handler)
+	Start(ctx context.Context)
+	Stop(ctx context.Context) error
+
+	Failed(ctx context.Context, page, size int) (*types.Page[types.Job], error)
+	Retry(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) (*types.Job, error)
+}
+
+type EnqueueOption func(*types.Job)
+
+// Delay postpones the first run of the job by d.
+func Delay(d time.Duration) EnqueueOption {
+	return func(j *types.Job) {
+		j.RunAt = time.Now().UTC().Add(d).Format(time.RFC3339)
+	}
+}
+
+// At schedules the first run of the job at t.
+func At(t time.Time) EnqueueOption {
+	return func(j *types.Job) {
+		j.RunAt = t.UTC().Format(time.RFC3339)
+	}
+}
+
+func MaxAttempts(n int) EnqueueOption {
+	return func(j *types.Job) {
+		if n > 0 {
+			j.MaxAttempts = n
+		}
+	}
+}
+
+// Decode unmarshals job payload into T.
+func Decode[T any](job types.Job) (T, error) {
+	var dest T
+	err := json.Unmarshal([]byte(job.Payload), &dest)
+	return dest, err
+}
+
+type queue struct {
+	repo   repos.Job
+	logger logging.Logger
+	opts   options
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+func NewQueue(repo repos.Job, logger logging.Logger, opts ...optionFunc) Queue {
+	return &queue{
+		repo:     repo,
+		logger:   logger,
+		opts:     newOptions(opts...),
+		handlers: make(map[string]Handler),
+	}
+}
+
+func (q *queue) Enqueue(ctx context.Context, kind string, payload any, opts ...EnqueueOption) (*types.Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, types.NewErrBadRequest(err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	job := types.Job{
+		Id:          uuid.NewString(),
+		Kind:        kind,
+		Payload:     string(body),
+		Status:      types.JobQueued,
+		MaxAttempts: q.opts.maxAttempts,
+		RunAt:       now,
+		Created:     now,
+		Updated:     now,
+	}
+	for _, opt := range opts {
+		opt(&job)
+	}
+	return q.repo.Create(ctx, job)
+}
+
+func (q *queue) Handle(kind string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+func (q *queue) handler(kind string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	h, ok := q.handlers[kind]
+	return h, ok
+}
+
+func (q *queue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+	q.wg.Add(1)
+	go q.poll(ctx)
+}
+
+// Stop stops polling and waits for in-flight jobs. Jobs that don't finish
+// in time keep their lease and are picked up again once it expires.
+func (q *queue) Stop(ctx context.Context) error {
+	if q.cancel == nil {
+		return nil
+	}
+	q.cancel()
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *queue) poll(ctx context.Context) {
+	defer q.wg.Done()
+	sem := make(chan struct{}, q.opts.workers)
+	ticker := time.NewTicker(q.opts.pollInterval)
+	defer ticker.Stop()
+	for {
+		free := q.opts.workers - len(sem)
+		if free > 0 {
+			claimed, err := q.repo.Claim(ctx, time.Now(), q.opts.lease, free)
+			if err != nil && ctx.Err() == nil {
+				q.logger.Error("failed to claim jobs", "error", err.Error())
+			}
+			for _, job := range claimed {
+				sem <- struct{}{}
+				q.wg.Add(1)
+				go func(job types.Job) {
+					defer q.wg.Done()
+					defer func() { <-sem }()
+					q.process(job)
+				}(job)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// process runs on a context detached from the poller so that Stop lets
+// in-flight jobs finish instead of cancelling them halfway.
+func (q *queue) process(job types.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), q.opts.lease)
+	defer cancel()
+
+	h, ok := q.handler(job.Kind)
+	if !ok {
+		q.bury(ctx, job, fmt.Sprintf("no handler registered for %q", job.Kind))
+		return
+	}
+
+	err := q.run(ctx, h, job)
+	if err == nil {
+		if err := q.repo.Complete(ctx, job.Id); err != nil {
+			q.logger.Error("failed to complete job", "job", job.Id, "error", err.Error())
+		}
+		return
+	}
+	if job.Attempts >= job.MaxAttempts {
+		q.bury(ctx, job, err.Error())
+		return
+	}
+	runAt := time.Now().Add(q.backoff(job.Attempts))
+	if err := q.repo.Reschedule(ctx, job.Id, err.Error(), runAt); err != nil {
+		q.logger.Error("failed to reschedule job", "job", job.Id, "error", err.Error())
+	}
+	q.logger.Warn("job failed, retrying", "job", job.Id, "kind", job.Kind, "attempt", job.Attempts, "run_at", runAt)
+}
+
+func (q *queue) run(ctx context.Context, h Handler, job types.Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %+v", rec)
+		}
+	}()
+	return h(ctx, job)
+}
+
+func (q *queue) bury(ctx context.Context, job types.Job, reason string) {
+	q.logger.Error("job moved to dead letter", "job", job.Id, "kind", job.Kind, "error", reason)
+	if err := q.repo.Bury(ctx, job.Id, reason); err != nil {
+		q.logger.Error("failed to bury job", "job", job.Id, "error", err.Error())
+	}
+}
+
+func (q *queue) backoff(attempt int) time.Duration {
+	d := q.opts.backoff
+	for i := 1; i < attempt && d < q.opts.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, q.opts.maxBackoff)
+}
+
+func (q *queue) Failed(ctx context.Context, page, size int) (*types.Page[types.Job], error) {
+	return q.repo.GetPageByStatus(ctx, types.JobDead, page, size)
+}
+
+func (q *queue) Retry(ctx context.Context, id string) error {
+	err := q.repo.Retry(ctx, id, time.Now())
+	if errors.Is(err, types.ErrNotFound) {
+		return types.NewErrNotFound(fmt.Errorf("dead job %s not found", id))
+	}
+	return err
+}
+
+func (q *queue) Delete(ctx context.Context, id string) (*types.Job, error) {
+	return q.repo.Delete(ctx, id)
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
-	"github.com/yosa12978/echoes/app"
+	"fmt"
+	"os"
+
+	"github.com/yosa12978/echoes/cli"
 )
 
 func main() {
-	if err := app.Run(); err != nil {
-		panic(err)
+	if err := cli.Run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,89 @@
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// colorVariables maps token types to variables of colorscheme.css. A token
+// uses the variable of its own type, or else of its subcategory or category.
+var colorVariables = map[chroma.TokenType]string{
+	chroma.Keyword:           "--code-keyword",
+	chroma.KeywordType:       "--code-type",
+	chroma.NameBuiltin:       "--code-type",
+	chroma.NameClass:         "--code-type",
+	chroma.NameFunction:      "--code-function",
+	chroma.LiteralString:     "--code-string",
+	chroma.LiteralNumber:     "--code-number",
+	chroma.Comment:           "--code-comment",
+	chroma.Operator:          "--code-operator",
+	chroma.Punctuation:       "--code-operator",
+	chroma.LineNumbers:       "--code-comment",
+	chroma.LineNumbersTable:  "--code-comment",
+	chroma.GenericDeleted:    "--danger",
+	chroma.GenericInserted:   "--success",
+	chroma.GenericHeading:    "--primary",
+	chroma.GenericSubheading: "--primary",
+}
+
+func colorVariable(t chroma.TokenType) string {
+	for _, tt := range []chroma.TokenType{t, t.SubCategory(), t.Category()} {
+		if v, ok := colorVariables[tt]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// WriteCSS writes the stylesheet for highlighted code. Code blocks take
+// their colors from --code-bg and --code-fg, and tokens from --code-keyword,
+// --code-string and the like when colorscheme.css sets them, falling back to
+// the colors of the highlighting style.
+func (r *Renderer) WriteCSS(w io.Writer) error {
+	if r.options.style == "none" {
+		return nil
+	}
+	style := styles.Get(r.options.style)
+	text := style.Get(chroma.Background).Colour
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.ClassPrefix(classPrefix),
+		chromahtml.WithLineNumbers(r.options.lineNumbers),
+		chromahtml.LineNumbersInTable(r.options.lineNumbers),
+	)
+	if err := formatter.WriteCSS(w, style); err != nil {
+		return err
+	}
+
+	// the rules below come later, so they win over the ones of the style
+	if _, err := fmt.Fprintf(w, "/* colorscheme */ .%[1]sbg, .%[1]schroma { color: var(--code-fg); background-color: var(--code-bg) }\n", classPrefix); err != nil {
+		return err
+	}
+	types := make([]chroma.TokenType, 0, len(chroma.StandardTypes))
+	for t := range chroma.StandardTypes {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	for _, t := range types {
+		variable := colorVariable(t)
+		class := chroma.StandardTypes[t]
+		if variable == "" || class == "" {
+			continue
+		}
+		// tokens in the plain text color follow --code-fg
+		fallback := "inherit"
+		if entry := style.Get(t); entry.Colour.IsSet() && entry.Colour != text {
+			fallback = entry.Colour.String()
+		}
+		if _, err := fmt.Fprintf(w, "/* %s */ .%[2]schroma .%[2]s%[3]s { color: var(%[4]s, %[5]s) }\n",
+			t, classPrefix, class, variable, fallback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// builtinShortcodes are available in every renderer unless replaced with
+// WithShortcode
+func builtinShortcodes() map[string]Shortcode {
+	return map[string]Shortcode{
+		"youtube": youtube,
+		"gist":    gist,
+		"figure":  figure,
+		"note":    note,
+	}
+}
+
+var (
+	youtubeID = regexp.MustCompile(`^[\w-]{6,20}$`)
+	gistPart  = regexp.MustCompile(`^[\w-]+$`)
+	noteType  = regexp.MustCompile(`^[a-z]+$`)
+)
+
+// youtube embeds a video: {{< youtube dQw4w9WgXcQ >}}
+func youtube(call Call) (string, error) {
+	id := call.Arg(0, "id")
+	if !youtubeID.MatchString(id) {
+		return "", fmt.Errorf("%q isn't a video id", id)
+	}
+	title := call.Arg(1, "title")
+	if title == "" {
+		title = "YouTube video"
+	}
+	return embed(embedCard{
+		attrs: `data-embed-src="https://www.youtube-nocookie.com/embed/` + id + `?autoplay=1"`,
+		title: title,
+		link:  "https://www.youtube.com/watch?v=" + id,
+		label: "Play " + title,
+		host:  "youtube-nocookie.com",
+	}), nil
+}
+
+// gist embeds a GitHub gist: {{< gist user/id >}}, or {{< gist user id file >}}
+// as in Hugo
+func gist(call Call) (string, error) {
+	user, id := call.Arg(0, "user"), call.Arg(1, "id")
+	file := call.Arg(2, "file")
+	if before, after, ok := strings.Cut(user, "/"); ok {
+		user, id, file = before, after, call.Arg(1, "file")
+	}
+	if !gistPart.MatchString(user) || !gistPart.MatchString(id) {
+		return "", errors.New("use {{< gist user/id >}}")
+	}
+	link := "https://gist.github.com/" + user + "/" + id
+	script := link + ".js"
+	if file != "" {
+		script += "?file=" + url.QueryEscape(file)
+	}
+	return embed(embedCard{
+		attrs: `data-embed-script="` + html.EscapeString(script) + `"`,
+		title: "Gist " + user + "/" + id,
+		link:  link,
+		label: "Show gist " + user + "/" + id,
+		host:  "github.com",
+	}), nil
+}
+
+// figure shows an image with a caption:
+// {{< figure src="/a.png" caption="A cat" alt="A cat on a mat" link="/a" >}}
+func figure(call Call) (string, error) {
+	src := call.Arg(0, "src")
+	if src == "" {
+		return "", errors.New("src is required")
+	}
+	caption := call.Arg(1, "caption")
+	alt := call.Arg(2, "alt")
+	if alt == "" {
+		alt = caption
+	}
+	img := `<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `" loading="lazy">`
+	if link := call.Named["link"]; link != "" {
+		img = `<a href="` + html.EscapeString(link) + `">` + img + `</a>`
+	}
+	var b strings.Builder
+	b.WriteString("\n\n<figure>\n" + img + "\n")
+	if caption != "" {
+		b.WriteString("<figcaption>" + html.EscapeString(caption) + "</figcaption>\n")
+	}
+	b.WriteString("</figure>\n\n")
+	return b.String(), nil
+}
+
+// note sets its Markdown content apart:
+// {{< note warning "Title" >}}Careful{{< /note >}}
+func note(call Call) (string, error) {
+	kind := call.Arg(0, "type")
+	if kind == "" {
+		kind = "info"
+	}
+	if !noteType.MatchString(kind) {
+		return "", fmt.Errorf("%q isn't a note type", kind)
+	}
+	var b strings.Builder
+	b.WriteString("\n\n" + `<div class="note note-` + kind + `">` + "\n")
+	if title := call.Arg(1, "title"); title != "" {
+		b.WriteString(`<p class="note-title">` + html.EscapeString(title) + "</p>\n")
+	}
+	// blank lines around the content make goldmark parse it as Markdown
+	b.WriteString("\n" + call.Inner + "\n\n</div>\n\n")
+	return b.String(), nil
+}
+
+// embedCard is a placeholder for third party content. Nothing is loaded
+// from the third party until the reader clicks it, script.js then swaps it
+// for an iframe. Without scripts, like in feeds, it is a plain link.
+type embedCard struct {
+	attrs string
+	title string
+	link  string
+	label string
+	host  string
+}
+
+func embed(c embedCard) string {
+	return "\n\n" + `<div class="embed" ` + c.attrs + ` data-embed-title="` + html.EscapeString(c.title) + `">` + "\n" +
+		`<p><a class="embed-load" href="` + html.EscapeString(c.link) + `">` + html.EscapeString(c.label) + "</a></p>\n" +
+		`<p class="embed-notice">Loads content from ` + c.host + "</p>\n" +
+		"</div>\n\n"
+}
@@ -0,0 +1,138 @@
+package markdown
+
+import (
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// Image is what an ImageResolver knows about an image: its size and copies
+// of it for smaller screens or in smaller formats
+type Image struct {
+	Type    string
+	Width   int
+	Height  int
+	Sources []ImageSource
+}
+
+type ImageSource struct {
+	URL   string
+	Type  string
+	Width int
+}
+
+// ImageResolver looks up the images posts link to, like the uploaded
+// ones. Images it doesn't know are rendered as they are.
+type ImageResolver interface {
+	ResolveImage(src string) (Image, bool)
+}
+
+// imageSizes tells browsers how wide images are displayed: the width of
+// the page, up to the width of the container
+const imageSizes = "(max-width: 650px) 100vw, 650px"
+
+// imageRenderer lazy loads every image. Resolved ones get their size, so
+// the page doesn't jump around while they load, and a srcset to pick the
+// copy that fits the screen from.
+type imageRenderer struct {
+	resolver ImageResolver
+}
+
+func (r *imageRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindImage, r.render)
+}
+
+func (r *imageRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.Image)
+	src := string(n.Destination)
+	attrs := ` alt="` + html.EscapeString(plainText(n, source)) + `"`
+	if n.Title != nil {
+		attrs += ` title="` + html.EscapeString(string(n.Title)) + `"`
+	}
+	attrs += ` loading="lazy"`
+
+	img, ok := Image{}, false
+	if r.resolver != nil {
+		img, ok = r.resolver.ResolveImage(src)
+	}
+	if !ok || img.Width <= 0 || img.Height <= 0 {
+		w.WriteString(`<img src="` + escapeURL(src) + `"` + attrs + ">")
+		return ast.WalkSkipChildren, nil
+	}
+
+	attrs += ` decoding="async" width="` + strconv.Itoa(img.Width) + `" height="` + strconv.Itoa(img.Height) + `"`
+	fallback, webp := sources(src, img)
+	if webp == nil {
+		if len(fallback) > 1 {
+			attrs = srcset(fallback) + attrs
+		}
+		w.WriteString(`<img src="` + escapeURL(src) + `"` + attrs + ">")
+		return ast.WalkSkipChildren, nil
+	}
+	if len(fallback) > 1 {
+		attrs = srcset(fallback) + attrs
+	}
+	w.WriteString(`<picture><source type="image/webp"` + srcset(webp) + ">")
+	w.WriteString(`<img src="` + escapeURL(src) + `"` + attrs + "></picture>")
+	return ast.WalkSkipChildren, nil
+}
+
+type candidate struct {
+	url   string
+	width int
+}
+
+// sources picks a URL for each width img is available in, in its own
+// type and, when there are any WebP copies, preferring WebP. Widths
+// without a WebP copy keep the other one, so small screens aren't sent a
+// large WebP instead.
+func sources(src string, img Image) (fallback, webp []candidate) {
+	webpURLs := map[int]string{}
+	for _, s := range img.Sources {
+		switch {
+		case s.Width <= 0 || s.Width > img.Width:
+		case s.Type == img.Type && s.Width < img.Width:
+			fallback = append(fallback, candidate{s.URL, s.Width})
+		case s.Type == "image/webp" && img.Type != "image/webp":
+			webpURLs[s.Width] = s.URL
+		}
+	}
+	fallback = append(fallback, candidate{src, img.Width})
+	slices.SortFunc(fallback, func(a, b candidate) int { return a.width - b.width })
+	if len(webpURLs) == 0 {
+		return fallback, nil
+	}
+	for _, c := range fallback {
+		if url, ok := webpURLs[c.width]; ok {
+			c.url = url
+		}
+		webp = append(webp, c)
+	}
+	return fallback, webp
+}
+
+// srcset lists candidates for an img or a source. An img with only its src
+// needs none, a source always does.
+func srcset(candidates []candidate) string {
+	list := make([]string, len(candidates))
+	for i, c := range candidates {
+		list[i] = escapeURL(c.url) + " " + strconv.Itoa(c.width) + "w"
+	}
+	return ` srcset="` + strings.Join(list, ", ") + `" sizes="` + imageSizes + `"`
+}
+
+func escapeURL(url string) string {
+	if gmhtml.IsDangerousURL([]byte(url)) {
+		return ""
+	}
+	return string(util.EscapeHTML(util.URLEscape([]byte(url), true)))
+}
@@ -0,0 +1,186 @@
+// Package markdown renders post content to HTML on the server.
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// version is part of every cache key. Bump it whenever the output of the
+// renderer changes, so posts rendered by an older build are rendered again.
+const version = "5"
+
+// classPrefix keeps highlighting classes apart from the ones of the site
+const classPrefix = "hl-"
+
+// Result is a rendered document
+type Result struct {
+	HTML string `json:"html"`
+	// TOC is a table of contents linking to the headings of the document,
+	// empty when it has none
+	TOC      string `json:"toc,omitempty"`
+	Headings int    `json:"headings,omitempty"`
+}
+
+type Renderer struct {
+	md          goldmark.Markdown
+	policy      *bluemonday.Policy
+	options     options
+	fingerprint string
+}
+
+// New returns a renderer for GitHub flavored Markdown with footnotes and
+// highlighted code blocks. Shortcodes are expanded first. Headings get
+// stable ids and, unless styles are inlined, a permalink anchor. Images are
+// lazy loaded. Raw HTML is allowed in the source and sanitized with the
+// rest of the output.
+func New(opts ...Option) *Renderer {
+	o := newOptions(opts...)
+	extensions := []goldmark.Extender{
+		extension.NewTable(
+			extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute),
+		),
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.TaskList,
+		extension.Footnote,
+	}
+	if o.style != "none" {
+		extensions = append(extensions, highlighting.NewHighlighting(
+			highlighting.WithStyle(o.style),
+			highlighting.WithFormatOptions(
+				chromahtml.WithClasses(!o.inline),
+				chromahtml.ClassPrefix(classPrefix),
+				chromahtml.WithLineNumbers(o.lineNumbers),
+				chromahtml.LineNumbersInTable(o.lineNumbers),
+			),
+		))
+	}
+	return &Renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extensions...),
+			goldmark.WithRendererOptions(
+				html.WithUnsafe(),
+				renderer.WithNodeRenderers(util.Prioritized(&imageRenderer{resolver: o.images}, 100)),
+			),
+		),
+		policy:      policy(o),
+		options:     o,
+		fingerprint: fingerprint(o),
+	}
+}
+
+// fingerprint identifies what the options change in the output
+func fingerprint(o options) string {
+	shortcodes := strings.Join(slices.Sorted(maps.Keys(o.shortcodes)), ",")
+	return fmt.Sprintf("%s:%s:%t:%t:%s:%t", version, o.style, o.lineNumbers, o.inline, shortcodes, o.images != nil)
+}
+
+var (
+	defaultRenderer *Renderer
+	defaultOnce     sync.Once
+)
+
+// Default is the renderer shared by templates that render small snippets,
+// like the announcement, without caching.
+func Default() *Renderer {
+	defaultOnce.Do(func() {
+		defaultRenderer = New()
+	})
+	return defaultRenderer
+}
+
+// Render converts src to sanitized HTML
+func (r *Renderer) Render(src string) (Result, error) {
+	source := []byte(expandShortcodes(src, r.options.shortcodes))
+	doc := r.md.Parser().Parse(text.NewReader(source))
+	found := headings(doc, source, !r.options.inline)
+
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, source, doc); err != nil {
+		return Result{}, err
+	}
+	return Result{
+		HTML:     r.policy.SanitizeReader(&buf).String(),
+		TOC:      toc(found),
+		Headings: len(found),
+	}, nil
+}
+
+// ShowTOC reports whether the table of contents of res is displayed. It is
+// when the post asks for one or has enough headings for WithTOC.
+func (r *Renderer) ShowTOC(res Result, enabled bool) bool {
+	if res.TOC == "" {
+		return false
+	}
+	threshold := r.options.tocMinHeadings
+	return enabled || (threshold > 0 && res.Headings >= threshold)
+}
+
+// Key identifies the output of Render for src. It changes with the content
+// and with the renderer, so it can be used as a cache key.
+func (r *Renderer) Key(src string) string {
+	sum := sha256.Sum256([]byte(r.fingerprint + "\x00" + src))
+	return hex.EncodeToString(sum[:])
+}
+
+// policy extends the user generated content policy with what goldmark
+// extensions emit. Embedded iframes were allowed by the old client side
+// renderer, so they are kept working for https sources.
+func policy(o options) *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// posts are written by the site owners, their links can be followed
+	p.RequireNoFollowOnLinks(false)
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	// heading ids are slugs of their text in any script, the global id
+	// rule of the policy only takes ASCII
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^(footnote-(ref|backref)|heading-anchor|embed-load)$`)).OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^(footnotes|embed|note note-[a-z]+)$`)).OnElements("div")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^(embed-notice|note-title)$`)).OnElements("p")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^shortcode-error$`)).OnElements("span")
+	// embeds are loaded by script.js from these once clicked
+	p.AllowAttrs("data-embed-src", "data-embed-script").Matching(regexp.MustCompile(`^https://`)).OnElements("div")
+	p.AllowAttrs("data-embed-title").Matching(bluemonday.Paragraph).OnElements("div")
+	p.AllowAttrs("loading").Matching(regexp.MustCompile(`^lazy$`)).OnElements("img")
+	p.AllowAttrs("decoding").Matching(regexp.MustCompile(`^async$`)).OnElements("img")
+	p.AllowElements("picture")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^image/[\w.+-]+$`)).OnElements("source")
+	p.AllowAttrs("srcset").Matching(regexp.MustCompile(`^[^"<>]+$`)).OnElements("img", "source")
+	p.AllowAttrs("sizes").Matching(regexp.MustCompile(`^[\w\s():,.%-]+$`)).OnElements("img", "source")
+	p.AllowAttrs("role").Matching(regexp.MustCompile(`^doc-(noteref|backlink|endnotes)$`)).OnElements("a", "div")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$`)).OnElements("input")
+	p.AllowAttrs("src").Matching(regexp.MustCompile(`^https://`)).OnElements("iframe")
+	p.AllowAttrs("width", "height").Matching(bluemonday.NumberOrPercent).OnElements("iframe")
+	p.AllowAttrs("allow", "title").Matching(bluemonday.Paragraph).OnElements("iframe")
+	p.AllowAttrs("allowfullscreen", "frameborder", "scrolling").OnElements("iframe")
+	highlighted := []string{"pre", "code", "span", "table", "tr", "td", "div"}
+	if o.inline {
+		p.AllowStyles(
+			"color", "background-color", "font-weight", "font-style", "text-decoration",
+			"display", "white-space", "margin", "margin-right", "padding", "border",
+			"border-spacing", "vertical-align", "width",
+		).OnElements(highlighted...)
+	} else {
+		p.AllowAttrs("class").Matching(regexp.MustCompile(`^` + classPrefix + `[\w-]+$`)).OnElements(highlighted...)
+	}
+	return p
+}
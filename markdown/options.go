@@ -0,0 +1,82 @@
+package markdown
+
+type Option func(*options)
+
+type options struct {
+	style       string
+	lineNumbers bool
+	inline      bool
+	// tocMinHeadings is the heading count from which posts get a table of
+	// contents, 0 leaves it to each post
+	tocMinHeadings int
+	shortcodes     map[string]Shortcode
+	images         ImageResolver
+}
+
+func defaultOptions() options {
+	return options{
+		style:      "catppuccin-latte",
+		shortcodes: builtinShortcodes(),
+	}
+}
+
+func newOptions(opts ...Option) options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithHighlightStyle sets the chroma style code blocks are highlighted
+// with. "none" turns highlighting off.
+func WithHighlightStyle(name string) Option {
+	return func(o *options) {
+		if name != "" {
+			o.style = name
+		}
+	}
+}
+
+func WithLineNumbers(enabled bool) Option {
+	return func(o *options) {
+		o.lineNumbers = enabled
+	}
+}
+
+// WithInlineStyles highlights code with style attributes instead of
+// classes, for HTML that is read without the site stylesheet, like feeds.
+func WithInlineStyles() Option {
+	return func(o *options) {
+		o.inline = true
+	}
+}
+
+// WithTOC shows a table of contents on posts with at least minHeadings
+// headings. Posts can ask for one regardless.
+func WithTOC(minHeadings int) Option {
+	return func(o *options) {
+		o.tocMinHeadings = minHeadings
+	}
+}
+
+// WithShortcode registers sc as {{< name >}}, replacing the built in
+// shortcode of that name. A nil sc removes it. Posts are cached as they
+// were rendered, run "echoes cache flush" after changing a shortcode.
+func WithShortcode(name string, sc Shortcode) Option {
+	return func(o *options) {
+		if sc == nil {
+			delete(o.shortcodes, name)
+			return
+		}
+		o.shortcodes[name] = sc
+	}
+}
+
+// WithImages looks up the images of posts in resolver to render them with
+// their size and a srcset
+func WithImages(resolver ImageResolver) Option {
+	return func(o *options) {
+		o.images = resolver
+	}
+}
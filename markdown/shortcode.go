@@ -0,0 +1,259 @@
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Shortcode expands {{< name args >}} in post content. The text it returns
+// replaces the shortcode in the Markdown source before parsing, so it can
+// be Markdown or HTML, which is sanitized with the rest of the post. Block
+// HTML must be separated from the text around it by blank lines.
+type Shortcode func(call Call) (string, error)
+
+// Call is a single use of a shortcode
+type Call struct {
+	Name string
+	// Args holds positional arguments and Named the key=value ones
+	Args  []string
+	Named map[string]string
+	// Inner is the content between {{< name >}} and {{< /name >}}, with
+	// its own shortcodes already expanded. It's empty for single tags.
+	Inner string
+}
+
+// Arg returns the named argument, or the positional one at i when it
+// isn't given by name
+func (c Call) Arg(i int, name string) string {
+	if v, ok := c.Named[name]; ok {
+		return v
+	}
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return ""
+}
+
+// shortcodeTag matches {{< name args >}} and {{< /name >}}. Written as
+// {{</* name */>}} it's printed as is, for posts about shortcodes.
+var shortcodeTag = regexp.MustCompile(`\{\{<(/\*)?\s*(/?)\s*([\w-]+)((?:"(?:[^"\\]|\\.)*"|[^>"])*?)\s*(\*/)?>\}\}`)
+
+type tag struct {
+	start, end int
+	closing    bool
+	name       string
+	args       string
+	escaped    bool
+}
+
+// expandShortcodes replaces the known shortcodes of src. Unknown ones and
+// the ones inside code are left alone.
+func expandShortcodes(src string, shortcodes map[string]Shortcode) string {
+	if len(shortcodes) == 0 || !strings.Contains(src, "{{<") {
+		return src
+	}
+	code := codeRanges(src)
+	var tags []tag
+	for _, m := range shortcodeTag.FindAllStringSubmatchIndex(src, -1) {
+		if inRanges(code, m[0]) {
+			continue
+		}
+		t := tag{
+			start:   m[0],
+			end:     m[1],
+			closing: m[5] > m[4],
+			name:    src[m[6]:m[7]],
+			args:    src[m[8]:m[9]],
+			escaped: m[2] >= 0 && m[10] >= 0,
+		}
+		if _, ok := shortcodes[t.name]; ok || t.escaped {
+			tags = append(tags, t)
+		}
+	}
+	e := expander{src: src, tags: tags, shortcodes: shortcodes}
+	return e.expand(0, len(src), 0, len(tags))
+}
+
+type expander struct {
+	src        string
+	tags       []tag
+	shortcodes map[string]Shortcode
+}
+
+// expand writes src[from:to] with the tags[first:last] that lie in it
+// expanded
+func (e *expander) expand(from, to, first, last int) string {
+	var b strings.Builder
+	pos := from
+	for i := first; i < last; i++ {
+		t := e.tags[i]
+		b.WriteString(e.src[pos:t.start])
+		pos = t.end
+		if t.escaped {
+			b.WriteString("{{<" + e.src[t.start+len("{{</*"):t.end-len("*/>}}")] + ">}}")
+			continue
+		}
+		if t.closing {
+			// a closing tag without its opening one
+			b.WriteString(e.src[t.start:t.end])
+			continue
+		}
+		call, err := parseArgs(t.name, t.args)
+		if end := e.closingTag(i, last); end > 0 {
+			call.Inner = strings.TrimSpace(e.expand(t.end, e.tags[end].start, i+1, end))
+			pos = e.tags[end].end
+			i = end
+		}
+		if err == nil {
+			var out string
+			if out, err = e.shortcodes[t.name](call); err == nil {
+				b.WriteString(out)
+				continue
+			}
+		}
+		b.WriteString(`<span class="shortcode-error">` + html.EscapeString(t.name+": "+err.Error()) + "</span>")
+	}
+	b.WriteString(e.src[pos:to])
+	return b.String()
+}
+
+// closingTag finds the tag closing tags[i] before tags[last], or returns 0
+func (e *expander) closingTag(i, last int) int {
+	depth := 0
+	for j := i + 1; j < last; j++ {
+		t := e.tags[j]
+		if t.escaped || t.name != e.tags[i].name {
+			continue
+		}
+		if !t.closing {
+			depth++
+			continue
+		}
+		if depth == 0 {
+			return j
+		}
+		depth--
+	}
+	return 0
+}
+
+// parseArgs splits args on spaces. Values can be quoted with double quotes
+// or backticks, and given by name with key=value.
+func parseArgs(name, args string) (Call, error) {
+	call := Call{Name: name, Named: map[string]string{}}
+	rest := strings.TrimSpace(args)
+	for rest != "" {
+		key := ""
+		if i := strings.IndexAny(rest, "= \t\n\"`"); i > 0 && rest[i] == '=' {
+			key, rest = rest[:i], rest[i+1:]
+		}
+		var value string
+		switch {
+		case rest == "":
+		case rest[0] == '"' || rest[0] == '`':
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return call, fmt.Errorf("unterminated quote in %s", rest)
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		default:
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		if key != "" {
+			call.Named[key] = value
+		} else {
+			call.Args = append(call.Args, value)
+		}
+		rest = strings.TrimSpace(rest)
+	}
+	return call, nil
+}
+
+var fence = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// codeRanges finds fenced code blocks and code spans, where shortcodes are
+// part of the code
+func codeRanges(src string) [][2]int {
+	var ranges [][2]int
+	open, marker := -1, ""
+	text := 0
+	for pos := 0; pos < len(src); {
+		end := strings.IndexByte(src[pos:], '\n')
+		if end < 0 {
+			end = len(src)
+		} else {
+			end += pos + 1
+		}
+		line := src[pos:end]
+		m := fence.FindStringSubmatch(line)
+		switch {
+		case open < 0 && m != nil:
+			ranges = append(ranges, codeSpans(src, text, pos)...)
+			open, marker = pos, m[1]
+		case open >= 0 && m != nil && m[1][0] == marker[0] && len(m[1]) >= len(marker) &&
+			strings.TrimSpace(line[len(m[0]):]) == "":
+			ranges = append(ranges, [2]int{open, end})
+			open, text = -1, end
+		}
+		pos = end
+	}
+	if open >= 0 {
+		return append(ranges, [2]int{open, len(src)})
+	}
+	return append(ranges, codeSpans(src, text, len(src))...)
+}
+
+// codeSpans finds `code` in src[from:to], the closing backticks have to be
+// as many as the opening ones
+func codeSpans(src string, from, to int) [][2]int {
+	var ranges [][2]int
+	for i := from; i < to; {
+		if src[i] != '`' {
+			i++
+			continue
+		}
+		n := 1
+		for i+n < to && src[i+n] == '`' {
+			n++
+		}
+		closing := -1
+		for j := i + n; j < to; {
+			if src[j] != '`' {
+				j++
+				continue
+			}
+			m := 1
+			for j+m < to && src[j+m] == '`' {
+				m++
+			}
+			if m == n {
+				closing = j + m
+				break
+			}
+			j += m
+		}
+		if closing < 0 {
+			i += n
+			continue
+		}
+		ranges = append(ranges, [2]int{i, closing})
+		i = closing
+	}
+	return ranges
+}
+
+func inRanges(ranges [][2]int, pos int) bool {
+	return slices.ContainsFunc(ranges, func(r [2]int) bool {
+		return pos >= r[0] && pos < r[1]
+	})
+}
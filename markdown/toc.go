@@ -0,0 +1,160 @@
+package markdown
+
+import (
+	"html"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// reservedIDs are used by the post page around the content, headings get a
+// suffix instead of taking them
+var reservedIDs = []string{"comments", "created", "post-content", "spinner", "create-post-alert"}
+
+// headingIDs generates ids from heading text. The same text always gives
+// the same id, so links to a section keep working when the post is edited
+// elsewhere. Repeated headings are numbered in order.
+type headingIDs struct {
+	used map[string]bool
+}
+
+func newHeadingIDs() *headingIDs {
+	ids := &headingIDs{used: make(map[string]bool, len(reservedIDs))}
+	for _, id := range reservedIDs {
+		ids.used[id] = true
+	}
+	return ids
+}
+
+func (s *headingIDs) generate(text string) string {
+	id := slug(text)
+	if id == "" {
+		id = "section"
+	}
+	unique := id
+	for i := 1; s.used[unique]; i++ {
+		unique = id + "-" + strconv.Itoa(i)
+	}
+	s.used[unique] = true
+	return unique
+}
+
+// slug keeps letters and digits of any script, lowercased, and joins words
+// with dashes
+func slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+type heading struct {
+	level int
+	id    string
+	text  string
+}
+
+// headings gives the headings of doc an id and collects them. A permalink
+// anchor is appended to each of them when anchors is set.
+func headings(doc ast.Node, src []byte, anchors bool) []heading {
+	ids := newHeadingIDs()
+	var res []heading
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		text := strings.TrimSpace(plainText(h, src))
+		id := ids.generate(text)
+		h.SetAttributeString("id", []byte(id))
+		res = append(res, heading{level: h.Level, id: id, text: text})
+		if anchors {
+			h.AppendChild(h, anchor(id))
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	return res
+}
+
+// plainText is the text of n without inline HTML tags
+func plainText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(src))
+			if c.SoftLineBreak() || c.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		case *ast.RawHTML:
+		default:
+			b.WriteString(plainText(c, src))
+		}
+	}
+	return b.String()
+}
+
+func anchor(id string) ast.Node {
+	link := ast.NewLink()
+	link.Destination = []byte("#" + id)
+	link.Title = []byte("Link to this section")
+	link.SetAttributeString("class", []byte("heading-anchor"))
+	link.AppendChild(link, ast.NewString([]byte("#")))
+	return link
+}
+
+// toc renders headings as nested lists. Levels are relative to the
+// highest heading, so posts that start at ## aren't indented once more.
+func toc(headings []heading) string {
+	if len(headings) == 0 {
+		return ""
+	}
+	top := headings[0].level
+	for _, h := range headings {
+		top = min(top, h.level)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<nav class="toc" aria-label="Contents">`)
+	depth := 0
+	for _, h := range headings {
+		level := h.level - top + 1
+		if level <= depth {
+			b.WriteString("</li>")
+			for ; depth > level; depth-- {
+				b.WriteString("</ul></li>")
+			}
+		} else {
+			// skipped levels get an item without a link of their own
+			for opened := false; depth < level; depth++ {
+				if opened {
+					b.WriteString("<li>")
+				}
+				b.WriteString("<ul>")
+				opened = true
+			}
+		}
+		b.WriteString(`<li><a href="#` + h.id + `">` + html.EscapeString(h.text) + "</a>")
+	}
+	b.WriteString("</li>")
+	for ; depth > 1; depth-- {
+		b.WriteString("</ul></li>")
+	}
+	b.WriteString("</ul></nav>")
+	return b.String()
+}
@@ -0,0 +1,260 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/HugoSmits86/nativewebp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Image is an uploaded image ready to be stored
+type Image struct {
+	// Data is the upload without its metadata
+	Data     []byte
+	Width    int
+	Height   int
+	Variants []Variant
+}
+
+// Variant is a resized or WebP copy of an image. It's stored under the key
+// of the image with Suffix before the extension.
+type Variant struct {
+	Suffix      string
+	Ext         string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// ProcessImage strips metadata like the EXIF location from an image and
+// makes copies of it narrower than each of widths. Copies are made in the
+// format of the image, plus WebP when that is smaller. Images that can't be
+// decoded, like AVIF, are returned as they are, while broken images and
+// images of more than maxPixels fail.
+func ProcessImage(data []byte, contentType string, widths []int) (*Image, error) {
+	res := &Image{Data: data}
+	var orientation int
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		res.Data, orientation, err = stripJPEG(data)
+	case "image/png":
+		res.Data, err = stripPNG(data)
+	case "image/webp":
+		res.Data, err = stripWebP(data)
+	case "image/gif":
+		// animations would be lost by resizing
+		cfg, err := gif.DecodeConfig(bytes.NewReader(data))
+		if err == nil {
+			err = checkSize(cfg)
+		}
+		if err == nil {
+			res.Data, err = stripGIF(data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid image: %w", err)
+		}
+		res.Width, res.Height = cfg.Width, cfg.Height
+		return res, nil
+	default:
+		return res, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+
+	img, err := decode(res.Data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	if orientation > 1 {
+		// the orientation was in the stripped EXIF, so it is applied to
+		// the pixels instead
+		img = orient(img, orientation)
+		if res.Data, err = encode(img, contentType, 92); err != nil {
+			return nil, err
+		}
+	}
+	res.Width, res.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	widths = slices.Sorted(slices.Values(widths))
+	for _, width := range slices.Compact(widths) {
+		if width <= 0 || width >= res.Width {
+			continue
+		}
+		height := max(1, res.Height*width/res.Width)
+		resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(resized, resized.Bounds(), img, img.Bounds(), draw.Src, nil)
+		variants, err := variants(resized, contentType, "-"+strconv.Itoa(width)+"w", len(res.Data))
+		if err != nil {
+			return nil, err
+		}
+		res.Variants = append(res.Variants, variants...)
+	}
+	if contentType != "image/webp" {
+		full, err := webpVariant(img, "", len(res.Data))
+		if err != nil {
+			return nil, err
+		}
+		if full != nil {
+			res.Variants = append(res.Variants, *full)
+		}
+	}
+	return res, nil
+}
+
+// variants encodes a resized image. Copies that aren't smaller than the
+// original, limit bytes, are left out.
+func variants(img image.Image, contentType, suffix string, limit int) ([]Variant, error) {
+	data, err := encode(img, contentType, 85)
+	if err != nil {
+		return nil, err
+	}
+	var res []Variant
+	if len(data) < limit {
+		size := img.Bounds().Size()
+		res = append(res, Variant{
+			Suffix:      suffix,
+			Ext:         extensions[contentType],
+			ContentType: contentType,
+			Width:       size.X,
+			Height:      size.Y,
+			Data:        data,
+		})
+		limit = len(data)
+	}
+	if contentType == "image/webp" {
+		return res, nil
+	}
+	webpCopy, err := webpVariant(img, suffix, limit)
+	if err != nil || webpCopy == nil {
+		return res, err
+	}
+	return append(res, *webpCopy), nil
+}
+
+// webpVariant encodes img as WebP if that takes less than limit bytes. The
+// encoder is lossless, so it mostly pays off for screenshots and drawings.
+func webpVariant(img image.Image, suffix string, limit int) (*Variant, error) {
+	data, err := encode(img, "image/webp", 0)
+	if err != nil || len(data) >= limit {
+		return nil, err
+	}
+	size := img.Bounds().Size()
+	return &Variant{
+		Suffix:      suffix,
+		Ext:         ".webp",
+		ContentType: "image/webp",
+		Width:       size.X,
+		Height:      size.Y,
+		Data:        data,
+	}, nil
+}
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// maxPixels is the most pixels an image may have to be decoded. Decoded
+// images take 4 bytes a pixel, so a small upload with huge dimensions could
+// take all the memory.
+const maxPixels = 50_000_000
+
+func checkSize(cfg image.Config) error {
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return errors.New("image has no pixels")
+	}
+	if cfg.Width > maxPixels/cfg.Height {
+		return fmt.Errorf("image is %dx%d, larger than %d megapixels", cfg.Width, cfg.Height, maxPixels/1_000_000)
+	}
+	return nil
+}
+
+// decode reads the dimensions of the image before the pixels, so images
+// larger than maxPixels are never decoded
+func decode(data []byte, contentType string) (image.Image, error) {
+	var decodeConfig func(io.Reader) (image.Config, error)
+	var decodeImage func(io.Reader) (image.Image, error)
+	switch contentType {
+	case "image/jpeg":
+		decodeConfig, decodeImage = jpeg.DecodeConfig, jpeg.Decode
+	case "image/png":
+		decodeConfig, decodeImage = png.DecodeConfig, png.Decode
+	case "image/webp":
+		decodeConfig, decodeImage = webp.DecodeConfig, webp.Decode
+	default:
+		return nil, fmt.Errorf("can't decode %s", contentType)
+	}
+	cfg, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(cfg); err != nil {
+		return nil, err
+	}
+	return decodeImage(bytes.NewReader(data))
+}
+
+func encode(img image.Image, contentType string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	case "image/png":
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	case "image/webp":
+		err = nativewebp.Encode(&buf, img, nil)
+	default:
+		err = fmt.Errorf("can't encode %s", contentType)
+	}
+	return buf.Bytes(), err
+}
+
+// orient turns img upright according to an EXIF orientation
+func orient(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if orientation >= 5 {
+		w, h = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = w-1-y, x
+			case 7:
+				dx, dy = w-1-y, h-1-x
+			case 8:
+				dx, dy = y, h-1-x
+			default:
+				dx, dy = x, y
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,99 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/yosa12978/echoes/types"
+)
+
+type local struct {
+	dir string
+}
+
+// NewLocal stores files in dir, which is created on the first upload
+func NewLocal(dir string) Store {
+	return &local{dir: dir}
+}
+
+func (s *local) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func (s *local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	// written next to the destination first, so a failed upload never
+	// leaves half a file behind
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, io.LimitReader(r, size)); err != nil {
+		tmp.Close()
+		return types.NewErrInternalFailure(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (s *local) Open(ctx context.Context, key string) (*Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, types.ErrNotFound
+		}
+		return nil, types.NewErrInternalFailure(err)
+	}
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		return nil, types.ErrNotFound
+	}
+	return &Object{
+		ReadSeekCloser: f,
+		// keys end with the extension of the sniffed type
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		Size:        info.Size(),
+		Modified:    info.ModTime(),
+	}, nil
+}
+
+func (s *local) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (s *local) URL(key string) string {
+	return servedAt + key
+}
@@ -0,0 +1,291 @@
+package media
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// The size of social preview images recommended by OpenGraph
+const (
+	PreviewWidth  = 1200
+	PreviewHeight = 630
+)
+
+// Preview is what goes on the social preview image of a post
+type Preview struct {
+	Title    string
+	SiteName string
+	// Footer is a line at the bottom, like the address of the site
+	Footer string
+	// Logo is drawn next to SiteName when it's set
+	Logo image.Image
+	Font *opentype.Font
+	// Colors come from the colorscheme, see PreviewColors
+	Background color.Color
+	Text       color.Color
+	Heading    color.Color
+	Accent     color.Color
+}
+
+// previewMargin is the space around the content of the image
+const previewMargin = 80
+
+// titleSizes are tried from the largest until the title fits in
+// titleLines lines
+var titleSizes = []float64{76, 64, 54, 46}
+
+const titleLines = 4
+
+// RenderPreview draws p as a PNG
+func RenderPreview(p Preview) ([]byte, error) {
+	if p.Font == nil {
+		return nil, errors.New("preview has no font")
+	}
+	img := image.NewRGBA(image.Rect(0, 0, PreviewWidth, PreviewHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(p.Background), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 16, PreviewHeight), image.NewUniform(p.Accent), image.Point{}, draw.Src)
+
+	// header: logo and site name
+	x := previewMargin
+	const logoSize = 96
+	if p.Logo != nil {
+		b := p.Logo.Bounds()
+		w, h := logoSize, logoSize
+		if b.Dx() > b.Dy() {
+			h = logoSize * b.Dy() / b.Dx()
+		} else {
+			w = logoSize * b.Dx() / b.Dy()
+		}
+		// the logo is cropped to a circle, the way the site header shows it
+		scaled := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), p.Logo, b, draw.Src, nil)
+		top := previewMargin + (logoSize-h)/2
+		draw.DrawMask(img, image.Rect(x, top, x+w, top+h), scaled, image.Point{}, circle{w, h}, image.Point{}, draw.Over)
+		x += w + 28
+	}
+	siteFace, err := newFace(p.Font, 40)
+	if err != nil {
+		return nil, err
+	}
+	defer siteFace.Close()
+	drawText(img, siteFace, p.Text, x, previewMargin+logoSize/2+14, p.SiteName)
+
+	// title, as large as it fits
+	var titleFace font.Face
+	var lines []string
+	for i, size := range titleSizes {
+		face, err := newFace(p.Font, size)
+		if err != nil {
+			return nil, err
+		}
+		lines = wrap(face, p.Title, PreviewWidth-2*previewMargin)
+		if len(lines) <= titleLines || i == len(titleSizes)-1 {
+			titleFace = face
+			break
+		}
+		face.Close()
+	}
+	defer titleFace.Close()
+	if len(lines) > titleLines {
+		lines = lines[:titleLines]
+		lines[titleLines-1] = fit(titleFace, lines[titleLines-1]+"…", PreviewWidth-2*previewMargin)
+	}
+	lineHeight := titleFace.Metrics().Height.Ceil() * 6 / 5
+	y := 250 + titleFace.Metrics().Ascent.Ceil()
+	for _, line := range lines {
+		drawText(img, titleFace, p.Heading, previewMargin, y, line)
+		y += lineHeight
+	}
+
+	if p.Footer != "" {
+		footerFace, err := newFace(p.Font, 30)
+		if err != nil {
+			return nil, err
+		}
+		defer footerFace.Close()
+		drawText(img, footerFace, p.Accent, previewMargin, PreviewHeight-previewMargin+10, p.Footer)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// circle is an alpha mask of the ellipse inside a w by h rectangle
+type circle struct{ w, h int }
+
+func (c circle) ColorModel() color.Model { return color.AlphaModel }
+
+func (c circle) Bounds() image.Rectangle { return image.Rect(0, 0, c.w, c.h) }
+
+func (c circle) At(x, y int) color.Color {
+	dx := (float64(x) + 0.5 - float64(c.w)/2) / (float64(c.w) / 2)
+	dy := (float64(y) + 0.5 - float64(c.h)/2) / (float64(c.h) / 2)
+	if dx*dx+dy*dy <= 1 {
+		return color.Opaque
+	}
+	return color.Transparent
+}
+
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+}
+
+func drawText(img draw.Image, face font.Face, c color.Color, x, y int, text string) {
+	d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(text)
+}
+
+// wrap breaks text into lines no wider than width. Words longer than a
+// line are broken where they overflow.
+func wrap(face font.Face, text string, width int) []string {
+	limit := fixed.I(width)
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if font.MeasureString(face, candidate) <= limit {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		for font.MeasureString(face, word) > limit {
+			head := fit(face, word, width)
+			lines = append(lines, head)
+			word = strings.TrimPrefix(word, head)
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fit cuts text to the longest start that is no wider than width. An
+// ellipsis at the end of text is kept.
+func fit(face font.Face, text string, width int) string {
+	limit := fixed.I(width)
+	if font.MeasureString(face, text) <= limit {
+		return text
+	}
+	suffix := ""
+	if strings.HasSuffix(text, "…") {
+		suffix = "…"
+		text = strings.TrimSuffix(text, "…")
+	}
+	runes := []rune(text)
+	for n := len(runes) - 1; n > 0; n-- {
+		if candidate := string(runes[:n]) + suffix; font.MeasureString(face, candidate) <= limit {
+			return candidate
+		}
+	}
+	return string(runes[:1])
+}
+
+// DecodeLogo reads a site logo. Raster images are decoded as they are, SVG
+// logos can only be drawn when they embed a bitmap.
+func DecodeLogo(data []byte) (image.Image, error) {
+	if !bytes.Contains(data[:min(len(data), 512)], []byte("<svg")) {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		return img, err
+	}
+	match := svgBitmap.FindSubmatch(data)
+	if match == nil {
+		return nil, errors.New("svg logo has no embedded bitmap")
+	}
+	encoded := strings.Join(strings.Fields(string(match[1])), "")
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	return img, err
+}
+
+var svgBitmap = regexp.MustCompile(`href="data:image/(?:png|jpeg|gif|webp);base64,([^"]+)"`)
+
+var (
+	cssComment  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssVariable = regexp.MustCompile(`--([\w-]+)\s*:\s*([^;]+);`)
+)
+
+// PreviewColors reads the variables of a colorscheme.css. Values that
+// aren't colors are left out.
+func PreviewColors(css []byte) map[string]color.Color {
+	colors := map[string]color.Color{}
+	for _, m := range cssVariable.FindAllSubmatch(cssComment.ReplaceAll(css, nil), -1) {
+		if c, err := parseColor(strings.TrimSpace(string(m[2]))); err == nil {
+			colors[string(m[1])] = c
+		}
+	}
+	return colors
+}
+
+// parseColor understands hex colors and rgb() or rgba()
+func parseColor(value string) (color.Color, error) {
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		if len(hex) == 3 || len(hex) == 4 {
+			var long strings.Builder
+			for _, r := range hex {
+				long.WriteRune(r)
+				long.WriteRune(r)
+			}
+			hex = long.String()
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 8 {
+			return nil, fmt.Errorf("invalid color %q", value)
+		}
+		return color.NRGBA{uint8(n >> 24), uint8(n >> 16), uint8(n >> 8), uint8(n)}, nil
+	}
+	args, ok := strings.CutPrefix(value, "rgba(")
+	if !ok {
+		args, ok = strings.CutPrefix(value, "rgb(")
+	}
+	args, closed := strings.CutSuffix(args, ")")
+	if !ok || !closed {
+		return nil, fmt.Errorf("invalid color %q", value)
+	}
+	parts := strings.Split(args, ",")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, fmt.Errorf("invalid color %q", value)
+	}
+	c := color.NRGBA{A: 255}
+	channels := []*uint8{&c.R, &c.G, &c.B}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid color %q", value)
+		}
+		if i == 3 {
+			c.A = uint8(min(max(v, 0), 1) * 255)
+			continue
+		}
+		*channels[i] = uint8(min(max(v, 0), 255))
+	}
+	return c, nil
+}
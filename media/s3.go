@@ -0,0 +1,117 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/yosa12978/echoes/types"
+)
+
+// S3Options configures a bucket of AWS S3, MinIO or another S3 compatible
+// service
+type S3Options struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	// PublicURL is the address the bucket is readable at, like a CDN. Files
+	// are served through echoes when it's empty.
+	PublicURL string
+}
+
+type s3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3 connects to the bucket, creating it if it doesn't exist yet
+func NewS3(ctx context.Context, opts S3Options) (Store, error) {
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure: opts.UseSSL,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("media: %w", err)
+	}
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("media: checking bucket %q: %w", opts.Bucket, err)
+	}
+	if !exists {
+		err := client.MakeBucket(ctx, opts.Bucket, minio.MakeBucketOptions{Region: opts.Region})
+		if err != nil {
+			return nil, fmt.Errorf("media: creating bucket %q: %w", opts.Bucket, err)
+		}
+	}
+	publicURL := opts.PublicURL
+	if publicURL != "" && !strings.HasSuffix(publicURL, "/") {
+		publicURL += "/"
+	}
+	return &s3{client: client, bucket: opts.Bucket, publicURL: publicURL}, nil
+}
+
+func (s *s3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+		// keys are never reused
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (s *s3) Open(ctx context.Context, key string) (*Object, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	// GetObject doesn't send a request, Stat is the first one
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, types.ErrNotFound
+		}
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &Object{
+		ReadSeekCloser: obj,
+		ContentType:    info.ContentType,
+		Size:           info.Size,
+		Modified:       info.LastModified,
+	}, nil
+}
+
+func (s *s3) Delete(ctx context.Context, key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (s *s3) URL(key string) string {
+	if s.publicURL != "" {
+		return s.publicURL + key
+	}
+	return servedAt + key
+}
@@ -0,0 +1,42 @@
+// Package media keeps uploaded files on the local disk or in an S3
+// compatible bucket.
+package media
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/yosa12978/echoes/types"
+)
+
+// Object is a stored file opened for reading
+type Object struct {
+	io.ReadSeekCloser
+	ContentType string
+	Size        int64
+	Modified    time.Time
+}
+
+type Store interface {
+	// Put stores size bytes of r under key, replacing what was there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns types.ErrNotFound when nothing is stored under key
+	Open(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+	// URL is the address the file under key is served at
+	URL(key string) string
+}
+
+// servedAt is where the server serves files from stores without a public
+// address of their own
+const servedAt = "/media/"
+
+// validKey rejects keys that would leave the store, like ../config.yaml
+func validKey(key string) error {
+	if !fs.ValidPath(key) || key == "." {
+		return types.NewErrBadRequest(fs.ErrInvalid)
+	}
+	return nil
+}
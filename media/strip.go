@@ -0,0 +1,240 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// The strip functions remove metadata, like where and with what camera a
+// photo was taken, without re-encoding the image. Color profiles are kept.
+// Data they can't parse fails, so metadata is never let through.
+
+var errTruncated = errors.New("image is truncated")
+
+// stripJPEG drops the APP1 (EXIF, XMP), APP13 (IPTC) and comment segments
+// and returns the EXIF orientation, which is lost with them
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, 0, errors.New("not a JPEG image")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	orientation := 0
+	pos := 2
+	for pos+2 <= len(data) {
+		if data[pos] != 0xff {
+			return nil, 0, errors.New("JPEG segment doesn't start with a marker")
+		}
+		marker := data[pos+1]
+		switch {
+		case marker == 0xff:
+			// fill byte
+			pos++
+			continue
+		case marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7):
+			// markers without a length
+			out = append(out, data[pos:pos+2]...)
+			pos += 2
+			continue
+		case marker == 0xda:
+			// start of scan, the image data follows
+			return append(out, data[pos:]...), orientation, nil
+		}
+		if pos+4 > len(data) {
+			return nil, 0, errTruncated
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end > len(data) || end < pos+4 {
+			return nil, 0, errTruncated
+		}
+		switch marker {
+		case 0xe1:
+			if o := exifOrientation(data[pos+4 : end]); o > 0 {
+				orientation = o
+			}
+		case 0xed, 0xfe:
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return nil, 0, errors.New("JPEG image has no image data")
+}
+
+// exifOrientation reads the orientation tag of an APP1 segment
+func exifOrientation(seg []byte) int {
+	tiff, ok := bytes.CutPrefix(seg, []byte("Exif\x00\x00"))
+	if !ok || len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	n := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < n; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			o := int(order.Uint16(tiff[entry+8:]))
+			if o < 1 || o > 8 {
+				return 0
+			}
+			return o
+		}
+	}
+	return 0
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// stripPNG drops the eXIf chunk and the text chunks, which hold the rest
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("not a PNG image")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	for pos := len(pngSignature); pos < len(data); {
+		if pos+12 > len(data) {
+			return nil, errTruncated
+		}
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:]))
+		if end > len(data) || end < pos {
+			return nil, errTruncated
+		}
+		switch string(data[pos+4 : pos+8]) {
+		case "eXIf", "tEXt", "iTXt", "zTXt":
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	return out, nil
+}
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags in the
+// VP8X header
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errors.New("not a WebP image")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, errTruncated
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2
+		if end == len(data)+1 {
+			// some encoders leave out the padding of the last chunk
+			end--
+		}
+		if end > len(data) || end < pos {
+			return nil, errTruncated
+		}
+		switch string(data[pos : pos+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[pos:end]...)
+			if size > 0 {
+				// the flags are in the first byte: 0x08 EXIF, 0x04 XMP
+				out[start+8] &^= 0x08 | 0x04
+			}
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+// gifLoops are the application extensions that make animations repeat,
+// every other one is metadata, like XMP
+var gifLoops = []string{"NETSCAPE2.0", "ANIMEXTS1.0"}
+
+// stripGIF drops comment extensions and application extensions other than
+// the loop count
+func stripGIF(data []byte) ([]byte, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, errors.New("not a GIF image")
+	}
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		// global color table
+		pos += 3 << (flags&0x07 + 1)
+	}
+	if pos > len(data) {
+		return nil, errTruncated
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:pos]...)
+	for pos < len(data) {
+		start := pos
+		keep := true
+		switch data[pos] {
+		case 0x3b:
+			// trailer
+			return append(out, data[pos]), nil
+		case 0x21:
+			if pos+2 > len(data) {
+				return nil, errTruncated
+			}
+			switch data[pos+1] {
+			case 0xfe:
+				keep = false
+			case 0xff:
+				app := pos + 2
+				keep = app+12 <= len(data) && data[app] == 11 &&
+					slices.Contains(gifLoops, string(data[app+1:app+12]))
+			}
+			pos += 2
+		case 0x2c:
+			// image descriptor, then the LZW code size
+			if pos+11 > len(data) {
+				return nil, errTruncated
+			}
+			if flags := data[pos+9]; flags&0x80 != 0 {
+				// local color table
+				pos += 3 << (flags&0x07 + 1)
+			}
+			pos += 11
+		default:
+			return nil, errors.New("unknown GIF block")
+		}
+		// data sub-blocks, ended by an empty one
+		for {
+			if pos >= len(data) {
+				return nil, errTruncated
+			}
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+		if pos > len(data) {
+			return nil, errTruncated
+		}
+		if keep {
+			out = append(out, data[start:pos]...)
+		}
+	}
+	return nil, errTruncated
+}
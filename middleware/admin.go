@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/yosa12978/echoes/session"
+	"github.com/yosa12978/echoes/utils"
 )
 
 func Admin(next http.Handler) http.Handler {
@@ -28,3 +29,21 @@ func Admin(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// APIAdmin guards the admin routes of the JSON API. Unlike Admin it doesn't
+// hide them, clients are told to log in instead.
+func APIAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := session.GetSession(r)
+		if err != nil || s == nil || !s.IsAuthenticated {
+			utils.WriteAPIError(w, http.StatusUnauthorized, "unauthorized", "log in as an admin first")
+			return
+		}
+		if !s.IsAdmin {
+			utils.WriteAPIError(w, http.StatusForbidden, "forbidden", "only admins can do this")
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		next.ServeHTTP(w, r)
+	})
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/yosa12978/echoes/types"
 	"github.com/yosa12978/echoes/utils"
 )
 
@@ -13,7 +14,7 @@ func Err404() func(http.Handler) http.Handler {
 			if r.Pattern == "" { // shitty idea. I need to completely flush http.ResponseWriter for it to work
 				w.Header().Set("Content-Type", "text/html")
 				w.WriteHeader(404)
-				if err := utils.RenderView(w, "err404", "Error 404", nil); err != nil {
+				if err := utils.RenderView(w, "err404", "Error 404", types.Meta{}, nil); err != nil {
 					http.Error(w, err.Error(), 500)
 				}
 			}
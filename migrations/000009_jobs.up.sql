@@ -0,0 +1,15 @@
+CREATE TABLE jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    kind VARCHAR(128) NOT NULL,
+    payload TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    created TIMESTAMP NOT NULL,
+    updated TIMESTAMP NOT NULL
+);
+
+CREATE INDEX jobs_status_run_at_idx ON jobs (status, run_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS posts_keyset_idx;
+
+DROP INDEX IF EXISTS comments_keyset_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX posts_keyset_idx ON posts (pinned DESC, created DESC, id DESC);
+
+CREATE INDEX comments_keyset_idx ON comments (postId, created DESC, id DESC);
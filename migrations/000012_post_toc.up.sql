@@ -0,0 +1 @@
+ALTER TABLE posts ADD COLUMN toc BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,10 @@
+CREATE TABLE media (
+    id VARCHAR(36) PRIMARY KEY,
+    name TEXT NOT NULL,
+    key TEXT NOT NULL UNIQUE,
+    content_type VARCHAR(128) NOT NULL,
+    size BIGINT NOT NULL,
+    created TEXT NOT NULL
+);
+
+CREATE INDEX media_created_idx ON media (created DESC, id DESC);
@@ -0,0 +1,3 @@
+DROP TABLE media_variants;
+ALTER TABLE media DROP COLUMN height;
+ALTER TABLE media DROP COLUMN width;
@@ -0,0 +1,13 @@
+ALTER TABLE media ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE media ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE media_variants (
+    media_id VARCHAR(36) NOT NULL REFERENCES media (id) ON DELETE CASCADE,
+    key TEXT NOT NULL UNIQUE,
+    content_type VARCHAR(128) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    size BIGINT NOT NULL
+);
+
+CREATE INDEX media_variants_media_id_idx ON media_variants (media_id);
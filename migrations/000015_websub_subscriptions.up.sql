@@ -0,0 +1,11 @@
+CREATE TABLE websub_subscriptions (
+    id VARCHAR(36) PRIMARY KEY,
+    callback TEXT NOT NULL,
+    topic TEXT NOT NULL,
+    secret TEXT NOT NULL DEFAULT '',
+    expires TEXT NOT NULL,
+    created TEXT NOT NULL,
+    UNIQUE (callback, topic)
+);
+
+CREATE INDEX websub_subscriptions_topic_idx ON websub_subscriptions (topic, expires);
@@ -0,0 +1,28 @@
+// Package migrations embeds the database schema and applies it.
+//
+// Applied versions are tracked in the schema_migrations table using the same
+// layout as golang-migrate, so databases migrated with the migrate/migrate
+// image keep working.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql sqlite/*.sql
+var files embed.FS
+
+// Postgres returns migrations for the postgres storage driver.
+func Postgres() fs.FS {
+	return files
+}
+
+// SQLite returns migrations for the sqlite storage driver.
+func SQLite() fs.FS {
+	sub, err := fs.Sub(files, "sqlite")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS jobs;
+
+DROP TABLE IF EXISTS accounts;
+
+DROP TABLE IF EXISTS comments;
+
+DROP TABLE IF EXISTS links;
+
+DROP TABLE IF EXISTS posts;
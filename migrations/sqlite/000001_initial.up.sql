@@ -0,0 +1,55 @@
+CREATE TABLE posts (
+    id VARCHAR(36) PRIMARY KEY,
+    title VARCHAR(256) NOT NULL,
+    content TEXT NOT NULL,
+    created TEXT NOT NULL,
+    pinned BOOLEAN NOT NULL DEFAULT false,
+    tweet BOOLEAN DEFAULT false
+);
+
+CREATE INDEX posts_keyset_idx ON posts (pinned DESC, created DESC, id DESC);
+
+CREATE TABLE links (
+    id VARCHAR(36) PRIMARY KEY,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    created TEXT NOT NULL,
+    icon VARCHAR(64),
+    place INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE comments (
+    id VARCHAR(36) PRIMARY KEY,
+    email TEXT NOT NULL,
+    name TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created TEXT NOT NULL,
+    postId VARCHAR(36) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX comments_keyset_idx ON comments (postId, created DESC, id DESC);
+
+CREATE TABLE accounts (
+    id VARCHAR(36) PRIMARY KEY,
+    username TEXT NOT NULL,
+    password TEXT NOT NULL,
+    created TEXT NOT NULL,
+    isAdmin BOOLEAN NOT NULL DEFAULT false,
+    salt VARCHAR(36) NOT NULL DEFAULT 0
+);
+
+CREATE TABLE jobs (
+    id VARCHAR(36) PRIMARY KEY,
+    kind VARCHAR(128) NOT NULL,
+    payload TEXT NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL DEFAULT 'queued',
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    last_error TEXT NOT NULL DEFAULT '',
+    run_at TEXT NOT NULL,
+    locked_until TEXT,
+    created TEXT NOT NULL,
+    updated TEXT NOT NULL
+);
+
+CREATE INDEX jobs_status_run_at_idx ON jobs (status, run_at);
@@ -0,0 +1,2 @@
+ALTER TABLE posts ADD COLUMN tags TEXT NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN draft BOOLEAN NOT NULL DEFAULT false;
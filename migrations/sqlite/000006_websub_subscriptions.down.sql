@@ -0,0 +1 @@
+DROP TABLE websub_subscriptions;
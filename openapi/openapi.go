@@ -0,0 +1,223 @@
+// Package openapi builds OpenAPI 3 documents. Routes are added with the
+// patterns of net/http, and schemas are generated from Go types.
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Tags       []Tag               `json:"tags,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lowercase methods to their operation
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{},
+		},
+	}
+}
+
+var wildcard = regexp.MustCompile(`\{(\w+)(?:\.\.\.)?\}`)
+
+// Path turns the path of a net/http pattern into an OpenAPI path:
+// "{key...}" becomes "{key}" and "/{$}" becomes "/"
+func Path(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, "{$}")
+	return wildcard.ReplaceAllString(pattern, "{$1}")
+}
+
+// Add documents the route, a pattern like "GET /posts/{id}". Path
+// parameters that op doesn't describe are added as required strings.
+func (d *Document) Add(route string, op Operation) {
+	method, pattern, ok := strings.Cut(route, " ")
+	if !ok {
+		panic(fmt.Sprintf("openapi: route %q has no method", route))
+	}
+	path := Path(pattern)
+	item, ok := d.Paths[path]
+	if !ok {
+		item = PathItem{}
+		d.Paths[path] = item
+	}
+	method = strings.ToLower(method)
+	if _, ok := item[method]; ok {
+		panic(fmt.Sprintf("openapi: route %q is added twice", route))
+	}
+
+	for _, m := range wildcard.FindAllStringSubmatch(pattern, -1) {
+		declared := false
+		for _, p := range op.Parameters {
+			declared = declared || (p.In == "path" && p.Name == m[1])
+		}
+		if !declared {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:     m[1],
+				In:       "path",
+				Required: true,
+				Schema:   &Schema{Type: "string"},
+			})
+		}
+	}
+	if op.Responses == nil {
+		op.Responses = map[string]Response{}
+	}
+	item[method] = &op
+}
+
+// Has tells whether the route, a net/http pattern, is documented
+func (d *Document) Has(route string) bool {
+	method, pattern, _ := strings.Cut(route, " ")
+	_, ok := d.Paths[Path(pattern)][strings.ToLower(method)]
+	return ok
+}
+
+// Routes lists the documented routes as "METHOD /path", sorted
+func (d *Document) Routes() []string {
+	var routes []string
+	for path, item := range d.Paths {
+		for method := range item {
+			routes = append(routes, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+// JSON describes a response with a body of schema
+func JSON(description string, schema *Schema) Response {
+	return Content(description, "application/json", schema)
+}
+
+// Content describes a response of any content type, schema may be nil
+func Content(description, contentType string, schema *Schema) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{contentType: {Schema: schema}},
+	}
+}
+
+// Empty describes a response without a body
+func Empty(description string) Response {
+	return Response{Description: description}
+}
+
+// Body describes a required request body
+func Body(contentType string, schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{contentType: {Schema: schema}},
+	}
+}
+
+// Query describes an optional query parameter
+func Query(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// Section is the operations of a tag, for listing them
+type Section struct {
+	Tag        Tag
+	Operations []Route
+}
+
+type Route struct {
+	Method string
+	Path   string
+	*Operation
+}
+
+// Sections lists the operations under their first tag, in the order of
+// d.Tags, then by path and method
+func (d *Document) Sections() []Section {
+	byTag := map[string][]Route{}
+	for _, route := range d.Routes() {
+		method, path, _ := strings.Cut(route, " ")
+		op := d.Paths[path][strings.ToLower(method)]
+		tag := ""
+		if len(op.Tags) > 0 {
+			tag = op.Tags[0]
+		}
+		byTag[tag] = append(byTag[tag], Route{Method: method, Path: path, Operation: op})
+	}
+	var sections []Section
+	for _, tag := range d.Tags {
+		if routes, ok := byTag[tag.Name]; ok {
+			sections = append(sections, Section{Tag: tag, Operations: routes})
+		}
+	}
+	return sections
+}
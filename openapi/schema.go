@@ -0,0 +1,151 @@
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+const refPrefix = "#/components/schemas/"
+
+// RefName is the name of the component s refers to, empty for inline
+// schemas
+func (s *Schema) RefName() string {
+	return strings.TrimPrefix(s.Ref, refPrefix)
+}
+
+// Name is a short description of the type of s, for people
+func (s *Schema) Name() string {
+	switch {
+	case s == nil:
+		return "any"
+	case s.Ref != "":
+		return s.RefName()
+	case s.Type == "array":
+		return "array of " + s.Items.Name()
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		return "map of " + s.AdditionalProperties.Name()
+	case s.Type == "":
+		return "any"
+	case s.Format != "":
+		return s.Type + " (" + s.Format + ")"
+	}
+	return s.Type
+}
+
+// Object is an inline object schema with the given string properties, all
+// of them required
+func Object(properties ...string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, Required: properties}
+	for _, p := range properties {
+		s.Properties[p] = &Schema{Type: "string"}
+	}
+	return s
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Schema describes the json encoding of v. Named structs become components
+// and are referred to.
+func (d *Document) Schema(v any) *Schema {
+	return d.schema(reflect.TypeOf(v))
+}
+
+func (d *Document) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.object(t)
+		}
+		name := componentName(t)
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// set first, so recursive types end
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.object(t)
+		}
+		return &Schema{Ref: refPrefix + name}
+	}
+	return &Schema{}
+}
+
+// object describes the fields of a struct the way encoding/json writes
+// them. Fields without a json tag are lowercased: the DTOs are read from
+// forms that send them so, and decoding ignores case anyway.
+func (d *Document) object(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, tagged := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && !tagged {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		s.Properties[name] = d.schema(field.Type)
+		if field.Tag.Get("json") != "" && !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+var (
+	packagePath = regexp.MustCompile(`(?:[\w.-]+/)*\w+\.(\w+)`)
+	sliceOf     = regexp.MustCompile(`\[\](\w+)`)
+)
+
+// componentName drops package paths from the name of t, so
+// "APIData[[]github.com/.../types.APILink]" is "APIData_APILinkList"
+func componentName(t reflect.Type) string {
+	name := packagePath.ReplaceAllString(t.Name(), "$1")
+	name = sliceOf.ReplaceAllString(name, "${1}List")
+	return strings.NewReplacer("[", "_", "]", "", ",", "_", "*", "").Replace(name)
+}
+
+// IsRequired tells whether the property name of s is required
+func (s *Schema) IsRequired(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
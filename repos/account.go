@@ -11,6 +11,7 @@ import (
 )
 
 type Account interface {
+	FindAll(ctx context.Context) ([]types.Account, error)
 	FindById(ctx context.Context, id string) (*types.Account, error)
 	FindByCredentials(ctx context.Context, username, passwordHash string) (*types.Account, error)
 	FindByUsername(ctx context.Context, username string) (*types.Account, error)
@@ -29,6 +30,16 @@ func NewAccountPostgres() Account {
 	return repo
 }
 
+type accountSQLite struct {
+	*account
+}
+
+func NewAccountSQLite() Account {
+	return &accountSQLite{
+		account: &account{db: data.SQLite()},
+	}
+}
+
 // type Account struct {
 // 	Id       string
 // 	Username string
@@ -37,10 +48,28 @@ func NewAccountPostgres() Account {
 //  IsAdmin bool
 // }
 
+func (repo *account) FindAll(ctx context.Context) ([]types.Account, error) {
+	accounts := []types.Account{}
+	q := "SELECT * FROM accounts ORDER BY created ASC;"
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q)
+	if err != nil {
+		return accounts, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var acc types.Account
+		if err := rows.Scan(&acc.Id, &acc.Username, &acc.Password, &acc.Created, &acc.IsAdmin, &acc.Salt); err != nil {
+			return accounts, types.NewErrInternalFailure(err)
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, nil
+}
+
 func (repo *account) FindById(ctx context.Context, id string) (*types.Account, error) {
 	var acc types.Account
 	q := "SELECT * FROM accounts WHERE id=$1;"
-	err := repo.db.QueryRowContext(ctx, q, id).Scan(&acc.Id, &acc.Username, &acc.Password, &acc.Created, &acc.IsAdmin, &acc.Salt)
+	err := data.Conn(ctx, repo.db).QueryRowContext(ctx, q, id).Scan(&acc.Id, &acc.Username, &acc.Password, &acc.Created, &acc.IsAdmin, &acc.Salt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, types.ErrNotFound
@@ -54,7 +83,7 @@ func (repo *account) FindById(ctx context.Context, id string) (*types.Account, e
 func (repo *account) FindByCredentials(ctx context.Context, username, passwordHash string) (*types.Account, error) {
 	var acc types.Account
 	q := "SELECT * FROM accounts WHERE username=$1 AND password=$2;"
-	err := repo.db.
+	err := data.Conn(ctx, repo.db).
 		QueryRowContext(ctx, q, strings.ToLower(username), passwordHash).
 		Scan(&acc.Id, &acc.Username, &acc.Password, &acc.Created, &acc.IsAdmin, &acc.Salt)
 	if err != nil {
@@ -69,7 +98,7 @@ func (repo *account) FindByCredentials(ctx context.Context, username, passwordHa
 func (repo *account) FindByUsername(ctx context.Context, username string) (*types.Account, error) {
 	var acc types.Account
 	q := "SELECT * FROM accounts WHERE username=$1;"
-	err := repo.db.
+	err := data.Conn(ctx, repo.db).
 		QueryRowContext(ctx, q, strings.ToLower(username)).
 		Scan(&acc.Id, &acc.Username, &acc.Password, &acc.Created, &acc.IsAdmin, &acc.Salt)
 	if err != nil {
@@ -83,7 +112,7 @@ func (repo *account) FindByUsername(ctx context.Context, username string) (*type
 
 func (repo *account) Create(ctx context.Context, account types.Account) (*types.Account, error) {
 	q := "INSERT INTO accounts (id, username, password, created, isadmin, salt) VALUES ($1, $2, $3, $4, $5, $6);"
-	_, err := repo.db.ExecContext(ctx, q,
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q,
 		account.Id,
 		strings.ToLower(account.Username),
 		account.Password, account.Created,
@@ -97,7 +126,7 @@ func (repo *account) Create(ctx context.Context, account types.Account) (*types.
 
 func (repo *account) Update(ctx context.Context, accountId string, account types.Account) error {
 	q := "UPDATE accounts SET username=$1, password=$2, isadmin=$3, salt=$4 WHERE id=$5;"
-	_, err := repo.db.ExecContext(ctx, q,
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q,
 		strings.ToLower(account.Username),
 		account.Password,
 		account.IsAdmin,
@@ -114,7 +143,7 @@ func (repo *account) Update(ctx context.Context, accountId string, account types
 
 func (repo *account) Delete(ctx context.Context, accountId string) error {
 	q := "DELETE FROM accounts WHERE id=$1;"
-	_, err := repo.db.ExecContext(ctx, q, accountId)
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q, accountId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return types.ErrNotFound
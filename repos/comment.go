@@ -13,12 +13,18 @@ type Comment interface {
 	FindAll(ctx context.Context) ([]types.Comment, error)
 	GetPage(ctx context.Context, postId string, page, size int) (*types.Page[types.Comment], error)
 	GetPageTime(ctx context.Context, time, postId string, page, size int) (*types.Page[types.Comment], error)
+	// GetPageAfter returns size comments of the post following cursor (or
+	// the first page if cursor is nil) using keyset pagination
+	GetPageAfter(ctx context.Context, postId string, cursor *types.CommentCursor, size int) (*types.Page[types.Comment], error)
 	FindById(ctx context.Context, id string) (*types.Comment, error)
 	FindByPostId(ctx context.Context, postId string) ([]types.Comment, error)
 	Create(ctx context.Context, comment types.Comment) (*types.Comment, error)
 	Update(ctx context.Context, id string, comment types.Comment) (*types.Comment, error)
 	Delete(ctx context.Context, id string) (*types.Comment, error)
 	GetCommentsCount(ctx context.Context, postId string) (int, error)
+	// GetLatest returns the newest size comments on published posts, with
+	// the titles of those posts
+	GetLatest(ctx context.Context, size int) ([]types.LatestComment, error)
 }
 
 type commentPostgres struct {
@@ -31,6 +37,16 @@ func NewCommentPostgres() Comment {
 	return repo
 }
 
+type commentSQLite struct {
+	*commentPostgres
+}
+
+func NewCommentSQLite() Comment {
+	return &commentSQLite{
+		commentPostgres: &commentPostgres{db: data.SQLite()},
+	}
+}
+
 /*
 
 type Comment struct {
@@ -46,7 +62,7 @@ type Comment struct {
 func (repo *commentPostgres) FindAll(ctx context.Context) ([]types.Comment, error) {
 	comments := []types.Comment{}
 	q := "SELECT * FROM comments ORDER BY created DESC;"
-	rows, err := repo.db.QueryContext(ctx, q)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return comments, nil
@@ -73,7 +89,7 @@ func (repo *commentPostgres) FindAll(ctx context.Context) ([]types.Comment, erro
 func (repo *commentPostgres) FindById(ctx context.Context, id string) (*types.Comment, error) {
 	var comment types.Comment
 	q := "SELECT * FROM comments WHERE id=$1;"
-	err := repo.db.QueryRowContext(ctx, q, id).
+	err := data.Conn(ctx, repo.db).QueryRowContext(ctx, q, id).
 		Scan(&comment.Id,
 			&comment.Email,
 			&comment.Name,
@@ -93,7 +109,7 @@ func (repo *commentPostgres) FindById(ctx context.Context, id string) (*types.Co
 func (repo *commentPostgres) FindByPostId(ctx context.Context, postId string) ([]types.Comment, error) {
 	comments := []types.Comment{}
 	q := "SELECT * FROM comments WHERE postid=$1;"
-	rows, err := repo.db.QueryContext(ctx, q, postId)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, postId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return comments, nil
@@ -118,7 +134,7 @@ func (repo *commentPostgres) FindByPostId(ctx context.Context, postId string) ([
 
 func (repo *commentPostgres) Create(ctx context.Context, comment types.Comment) (*types.Comment, error) {
 	q := "INSERT INTO comments (id, email, name, content, created, postid) VALUES ($1, $2, $3, $4, $5, $6);"
-	_, err := repo.db.ExecContext(ctx, q,
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q,
 		comment.Id,
 		comment.Email,
 		comment.Name,
@@ -134,7 +150,7 @@ func (repo *commentPostgres) Create(ctx context.Context, comment types.Comment)
 
 func (repo *commentPostgres) Update(ctx context.Context, id string, comment types.Comment) (*types.Comment, error) {
 	q := "UPDATE comments SET email=$1, name=$2, content=$3 WHERE id=$4;"
-	_, err := repo.db.ExecContext(ctx, q, comment.Email, comment.Name, comment.Content, id)
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q, comment.Email, comment.Name, comment.Content, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, types.ErrNotFound
@@ -154,7 +170,7 @@ func (repo *commentPostgres) Delete(ctx context.Context, id string) (*types.Comm
 		return nil, types.NewErrInternalFailure(err)
 	}
 	q := "DELETE FROM comments WHERE id=$1;"
-	_, err = repo.db.ExecContext(ctx, q, id)
+	_, err = data.Conn(ctx, repo.db).ExecContext(ctx, q, id)
 	if err != nil {
 		return nil, types.NewErrInternalFailure(err)
 	}
@@ -165,13 +181,13 @@ func (repo *commentPostgres) GetPage(ctx context.Context, postId string, page, s
 	comments := []types.Comment{}
 	qcount := "SELECT COUNT(*) FROM comments WHERE postId=$1;"
 	var count int
-	repo.db.QueryRowContext(ctx, qcount, postId).Scan(&count)
+	data.Conn(ctx, repo.db).QueryRowContext(ctx, qcount, postId).Scan(&count)
 	hasNext := true
 	if (page-1)*size+size >= count {
 		hasNext = false
 	}
 	q := "SELECT * FROM comments WHERE postId=$1 ORDER BY created DESC LIMIT $2 OFFSET $3;"
-	rows, err := repo.db.QueryContext(ctx, q, postId, size, (page-1)*size)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, postId, size, (page-1)*size)
 	if err != nil {
 		return &types.Page[types.Comment]{
 			Content:  comments,
@@ -206,7 +222,7 @@ func (repo *commentPostgres) GetPage(ctx context.Context, postId string, page, s
 func (repo *commentPostgres) GetCommentsCount(ctx context.Context, postId string) (int, error) {
 	q := "SELECT COUNT(*) FROM comments WHERE postId=$1;"
 	var count int
-	err := repo.db.QueryRowContext(ctx, q, postId).Scan(&count)
+	err := data.Conn(ctx, repo.db).QueryRowContext(ctx, q, postId).Scan(&count)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, types.ErrNotFound
@@ -221,13 +237,13 @@ func (repo *commentPostgres) GetPageTime(
 	comments := []types.Comment{}
 	qcount := "SELECT COUNT(*) FROM comments WHERE postId=$1 AND created <= $2;"
 	var count int
-	repo.db.QueryRowContext(ctx, qcount, postId, time).Scan(&count)
+	data.Conn(ctx, repo.db).QueryRowContext(ctx, qcount, postId, time).Scan(&count)
 	hasNext := true
 	if (page-1)*size+size >= count {
 		hasNext = false
 	}
 	q := "SELECT * FROM comments WHERE postId=$1 AND created <= $4 ORDER BY created DESC LIMIT $2 OFFSET $3;"
-	rows, err := repo.db.QueryContext(ctx, q, postId, size, (page-1)*size, time)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, postId, size, (page-1)*size, time)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return &types.Page[types.Comment]{
@@ -267,3 +283,90 @@ func (repo *commentPostgres) GetPageTime(
 		Total:    count,
 	}, nil
 }
+
+func (repo *commentPostgres) GetPageAfter(
+	ctx context.Context,
+	postId string,
+	cursor *types.CommentCursor,
+	size int,
+) (*types.Page[types.Comment], error) {
+	comments := []types.Comment{}
+	q := "SELECT * FROM comments WHERE postId=$1 ORDER BY created DESC, id DESC LIMIT $2;"
+	args := []any{postId, size + 1}
+	if cursor != nil {
+		q = `
+			SELECT * FROM comments WHERE postId=$1 AND (created, id) < ($3, $4) 
+			ORDER BY created DESC, id DESC LIMIT $2;
+		`
+		args = append(args, cursor.Created, cursor.Id)
+	}
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return &types.Page[types.Comment]{
+			Content: comments,
+			HasNext: false,
+			Size:    size,
+		}, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		comment := types.Comment{}
+		rows.Scan(
+			&comment.Id,
+			&comment.Email,
+			&comment.Name,
+			&comment.Content,
+			&comment.Created,
+			&comment.PostId,
+		)
+		comments = append(comments, comment)
+	}
+	return commentKeysetPage(comments, size), nil
+}
+
+func commentKeysetPage(comments []types.Comment, size int) *types.Page[types.Comment] {
+	page := &types.Page[types.Comment]{
+		Content: comments,
+		Size:    size,
+	}
+	if len(comments) > size {
+		page.Content = comments[:size]
+		last := page.Content[size-1]
+		page.HasNext = true
+		page.NextCursor = types.EncodeCursor(types.CommentCursor{
+			Created: last.Created,
+			Id:      last.Id,
+		})
+	}
+	return page
+}
+
+func (repo *commentPostgres) GetLatest(ctx context.Context, size int) ([]types.LatestComment, error) {
+	comments := []types.LatestComment{}
+	q := `
+		SELECT c.id, c.email, c.name, c.content, c.created, c.postid, p.title FROM comments c
+		JOIN posts p ON p.id = c.postid WHERE NOT p.draft
+		ORDER BY c.created DESC, c.id DESC LIMIT $1;
+	`
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, size)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var comment types.LatestComment
+		if err := rows.Scan(
+			&comment.Id,
+			&comment.Email,
+			&comment.Name,
+			&comment.Content,
+			&comment.Created,
+			&comment.PostId,
+			&comment.PostTitle,
+		); err != nil {
+			return nil, types.NewErrInternalFailure(err)
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
@@ -0,0 +1,234 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/yosa12978/echoes/data"
+	"github.com/yosa12978/echoes/types"
+)
+
+type Job interface {
+	Create(ctx context.Context, job types.Job) (*types.Job, error)
+	// Claim locks up to limit jobs that are due at now (or whose lease has
+	// expired) and marks them as running until now+lease.
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error)
+	Complete(ctx context.Context, id string) error
+	Reschedule(ctx context.Context, id, lastError string, runAt time.Time) error
+	Bury(ctx context.Context, id, lastError string) error
+	Retry(ctx context.Context, id string, now time.Time) error
+	Delete(ctx context.Context, id string) (*types.Job, error)
+	FindById(ctx context.Context, id string) (*types.Job, error)
+	GetPageByStatus(ctx context.Context, status string, page, size int) (*types.Page[types.Job], error)
+}
+
+type jobPostgres struct {
+	db *sql.DB
+}
+
+func NewJobPostgres() Job {
+	repo := new(jobPostgres)
+	repo.db = data.Postgres()
+	return repo
+}
+
+// jobTime formats timestamps the same way the rest of the app stores them,
+// so that sqlite can compare them as plain strings
+func jobTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+const jobColumns = "id, kind, payload, status, attempts, max_attempts, last_error, run_at, created, updated"
+
+func scanJob(row interface{ Scan(...any) error }, job *types.Job) error {
+	return row.Scan(
+		&job.Id,
+		&job.Kind,
+		&job.Payload,
+		&job.Status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.LastError,
+		&job.RunAt,
+		&job.Created,
+		&job.Updated,
+	)
+}
+
+func (repo *jobPostgres) Create(ctx context.Context, job types.Job) (*types.Job, error) {
+	q := `
+		INSERT INTO jobs (id, kind, payload, status, attempts, max_attempts, last_error, run_at, created, updated)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+	`
+	_, err := repo.db.ExecContext(ctx, q,
+		job.Id,
+		job.Kind,
+		job.Payload,
+		job.Status,
+		job.Attempts,
+		job.MaxAttempts,
+		job.LastError,
+		job.RunAt,
+		job.Created,
+		job.Updated,
+	)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &job, nil
+}
+
+func (repo *jobPostgres) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	q := `
+		UPDATE jobs SET status='running', attempts=attempts+1, locked_until=$2, updated=$1
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE (status='queued' AND run_at <= $1) OR (status='running' AND locked_until < $1)
+			ORDER BY run_at ASC LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + jobColumns + `;
+	`
+	return repo.claim(ctx, q, now, lease, limit)
+}
+
+func (repo *jobPostgres) claim(ctx context.Context, q string, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	jobs := []types.Job{}
+	rows, err := repo.db.QueryContext(ctx, q, jobTime(now), jobTime(now.Add(lease)), limit)
+	if err != nil {
+		return jobs, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var job types.Job
+		if err := scanJob(rows, &job); err != nil {
+			return jobs, types.NewErrInternalFailure(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (repo *jobPostgres) Complete(ctx context.Context, id string) error {
+	q := "DELETE FROM jobs WHERE id=$1;"
+	if _, err := repo.db.ExecContext(ctx, q, id); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (repo *jobPostgres) Reschedule(ctx context.Context, id, lastError string, runAt time.Time) error {
+	q := "UPDATE jobs SET status='queued', last_error=$1, run_at=$2, locked_until=NULL, updated=$3 WHERE id=$4;"
+	_, err := repo.db.ExecContext(ctx, q, lastError, jobTime(runAt), jobTime(time.Now()), id)
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (repo *jobPostgres) Bury(ctx context.Context, id, lastError string) error {
+	q := "UPDATE jobs SET status='dead', last_error=$1, locked_until=NULL, updated=$2 WHERE id=$3;"
+	_, err := repo.db.ExecContext(ctx, q, lastError, jobTime(time.Now()), id)
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (repo *jobPostgres) Retry(ctx context.Context, id string, now time.Time) error {
+	q := "UPDATE jobs SET status='queued', attempts=0, run_at=$1, updated=$1 WHERE id=$2 AND status='dead';"
+	res, err := repo.db.ExecContext(ctx, q, jobTime(now), id)
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return types.ErrNotFound
+	}
+	return nil
+}
+
+func (repo *jobPostgres) FindById(ctx context.Context, id string) (*types.Job, error) {
+	var job types.Job
+	q := "SELECT " + jobColumns + " FROM jobs WHERE id=$1;"
+	if err := scanJob(repo.db.QueryRowContext(ctx, q, id), &job); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrNotFound
+		}
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &job, nil
+}
+
+func (repo *jobPostgres) Delete(ctx context.Context, id string) (*types.Job, error) {
+	job, err := repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	q := "DELETE FROM jobs WHERE id=$1;"
+	if _, err := repo.db.ExecContext(ctx, q, id); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return job, nil
+}
+
+func (repo *jobPostgres) GetPageByStatus(ctx context.Context, status string, page, size int) (*types.Page[types.Job], error) {
+	jobs := []types.Job{}
+	qcount := "SELECT COUNT(*) FROM jobs WHERE status=$1;"
+	var count int
+	repo.db.QueryRowContext(ctx, qcount, status).Scan(&count)
+	hasNext := true
+	if (page-1)*size+size >= count {
+		hasNext = false
+	}
+	q := "SELECT " + jobColumns + " FROM jobs WHERE status=$1 ORDER BY updated DESC LIMIT $2 OFFSET $3;"
+	rows, err := repo.db.QueryContext(ctx, q, status, size, (page-1)*size)
+	if err != nil {
+		return &types.Page[types.Job]{
+			Content:  jobs,
+			HasNext:  false,
+			Size:     size,
+			NextPage: 1,
+			Total:    0,
+		}, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var job types.Job
+		scanJob(rows, &job)
+		jobs = append(jobs, job)
+	}
+	return &types.Page[types.Job]{
+		Content:  jobs,
+		HasNext:  hasNext,
+		Size:     size,
+		NextPage: page + 1,
+		Total:    count,
+	}, nil
+}
+
+type jobSQLite struct {
+	*jobPostgres
+}
+
+func NewJobSQLite() Job {
+	return &jobSQLite{
+		jobPostgres: &jobPostgres{db: data.SQLite()},
+	}
+}
+
+// Claim doesn't need SKIP LOCKED on sqlite: writes are serialized, so the
+// single UPDATE below can't hand the same job to two workers
+func (repo *jobSQLite) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]types.Job, error) {
+	q := `
+		UPDATE jobs SET status='running', attempts=attempts+1, locked_until=$2, updated=$1
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE (status='queued' AND run_at <= $1) OR (status='running' AND locked_until < $1)
+			ORDER BY run_at ASC LIMIT $3
+		)
+		RETURNING ` + jobColumns + `;
+	`
+	return repo.claim(ctx, q, now, lease, limit)
+}
@@ -38,10 +38,20 @@ func NewLinkPostgres() Link {
 	return repo
 }
 
+type linkSQLite struct {
+	*linkPostgres
+}
+
+func NewLinkSQLite() Link {
+	return &linkSQLite{
+		linkPostgres: &linkPostgres{db: data.SQLite()},
+	}
+}
+
 func (repo *linkPostgres) FindAll(ctx context.Context) ([]types.Link, error) {
 	links := []types.Link{}
 	q := "SELECT * FROM links ORDER BY place ASC"
-	rows, err := repo.db.QueryContext(ctx, q)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return links, nil
@@ -60,7 +70,7 @@ func (repo *linkPostgres) FindAll(ctx context.Context) ([]types.Link, error) {
 func (repo *linkPostgres) FindById(ctx context.Context, id string) (*types.Link, error) {
 	var link types.Link
 	q := "SELECT * FROM links WHERE id=$1;"
-	err := repo.db.QueryRowContext(ctx, q, id).
+	err := data.Conn(ctx, repo.db).QueryRowContext(ctx, q, id).
 		Scan(
 			&link.Id,
 			&link.Name,
@@ -80,7 +90,7 @@ func (repo *linkPostgres) FindById(ctx context.Context, id string) (*types.Link,
 
 func (repo *linkPostgres) Create(ctx context.Context, link types.Link) (*types.Link, error) {
 	q := "INSERT INTO links (id, name, url, created, icon, place) VALUES ($1, $2, $3, $4, $5, $6);"
-	_, err := repo.db.ExecContext(ctx, q, link.Id, link.Name, link.URL, link.Created, link.Icon, link.Place)
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q, link.Id, link.Name, link.URL, link.Created, link.Icon, link.Place)
 	if err != nil {
 		return nil, types.NewErrInternalFailure(err)
 	}
@@ -89,7 +99,7 @@ func (repo *linkPostgres) Create(ctx context.Context, link types.Link) (*types.L
 
 func (repo *linkPostgres) Update(ctx context.Context, id string, link types.Link) (*types.Link, error) {
 	q := "UPDATE links SET name=$1, url=$2, created=$3, icon=$4, place=$5 WHERE id=$6;"
-	_, err := repo.db.ExecContext(ctx, q, link.Name, link.URL, link.Created, link.Icon, link.Place, id)
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q, link.Name, link.URL, link.Created, link.Icon, link.Place, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, types.ErrNotFound
@@ -105,7 +115,7 @@ func (repo *linkPostgres) Delete(ctx context.Context, id string) (*types.Link, e
 		return nil, err
 	}
 	q := "DELETE FROM links WHERE id=$1;"
-	_, err = repo.db.ExecContext(ctx, q, id)
+	_, err = data.Conn(ctx, repo.db).ExecContext(ctx, q, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, types.ErrNotFound
@@ -0,0 +1,179 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/yosa12978/echoes/data"
+	"github.com/yosa12978/echoes/types"
+)
+
+type Media interface {
+	GetPage(ctx context.Context, page, size int) (*types.Page[types.Media], error)
+	FindById(ctx context.Context, id string) (*types.Media, error)
+	FindByKey(ctx context.Context, key string) (*types.Media, error)
+	Create(ctx context.Context, media types.Media) (*types.Media, error)
+	Delete(ctx context.Context, id string) (*types.Media, error)
+}
+
+type mediaPostgres struct {
+	db *sql.DB
+}
+
+func NewMediaPostgres() Media {
+	return &mediaPostgres{db: data.Postgres()}
+}
+
+type mediaSQLite struct {
+	*mediaPostgres
+}
+
+func NewMediaSQLite() Media {
+	return &mediaSQLite{
+		mediaPostgres: &mediaPostgres{db: data.SQLite()},
+	}
+}
+
+const mediaColumns = "id, name, key, content_type, size, created, width, height"
+
+func scanMedia(row interface{ Scan(...any) error }, media *types.Media) error {
+	return row.Scan(
+		&media.Id,
+		&media.Name,
+		&media.Key,
+		&media.ContentType,
+		&media.Size,
+		&media.Created,
+		&media.Width,
+		&media.Height,
+	)
+}
+
+func (repo *mediaPostgres) GetPage(ctx context.Context, page, size int) (*types.Page[types.Media], error) {
+	media := []types.Media{}
+	var count int
+	repo.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM media;").Scan(&count)
+	q := "SELECT " + mediaColumns + " FROM media ORDER BY created DESC, id DESC LIMIT $1 OFFSET $2;"
+	rows, err := repo.db.QueryContext(ctx, q, size, (page-1)*size)
+	if err != nil {
+		return &types.Page[types.Media]{
+			Content:  media,
+			Size:     size,
+			NextPage: 1,
+		}, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m types.Media
+		if err := scanMedia(rows, &m); err != nil {
+			return nil, types.NewErrInternalFailure(err)
+		}
+		media = append(media, m)
+	}
+	rows.Close()
+	for i := range media {
+		variants, err := repo.variants(ctx, media[i].Id)
+		if err != nil {
+			return nil, err
+		}
+		media[i].Variants = variants
+	}
+	return &types.Page[types.Media]{
+		Content:  media,
+		HasNext:  page*size < count,
+		Size:     size,
+		NextPage: page + 1,
+		Total:    count,
+	}, nil
+}
+
+func (repo *mediaPostgres) FindById(ctx context.Context, id string) (*types.Media, error) {
+	return repo.find(ctx, "id", id)
+}
+
+func (repo *mediaPostgres) FindByKey(ctx context.Context, key string) (*types.Media, error) {
+	return repo.find(ctx, "key", key)
+}
+
+// find looks media up by a unique column
+func (repo *mediaPostgres) find(ctx context.Context, column, value string) (*types.Media, error) {
+	var media types.Media
+	q := "SELECT " + mediaColumns + " FROM media WHERE " + column + "=$1;"
+	if err := scanMedia(repo.db.QueryRowContext(ctx, q, value), &media); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrNotFound
+		}
+		return nil, types.NewErrInternalFailure(err)
+	}
+	variants, err := repo.variants(ctx, media.Id)
+	if err != nil {
+		return nil, err
+	}
+	media.Variants = variants
+	return &media, nil
+}
+
+func (repo *mediaPostgres) variants(ctx context.Context, id string) ([]types.MediaVariant, error) {
+	variants := []types.MediaVariant{}
+	q := "SELECT key, content_type, width, height, size FROM media_variants WHERE media_id=$1 ORDER BY width, key;"
+	rows, err := repo.db.QueryContext(ctx, q, id)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v types.MediaVariant
+		if err := rows.Scan(&v.Key, &v.ContentType, &v.Width, &v.Height, &v.Size); err != nil {
+			return nil, types.NewErrInternalFailure(err)
+		}
+		variants = append(variants, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return variants, nil
+}
+
+func (repo *mediaPostgres) Create(ctx context.Context, media types.Media) (*types.Media, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	defer tx.Rollback()
+	q := "INSERT INTO media (" + mediaColumns + ") VALUES ($1, $2, $3, $4, $5, $6, $7, $8);"
+	_, err = tx.ExecContext(ctx, q,
+		media.Id,
+		media.Name,
+		media.Key,
+		media.ContentType,
+		media.Size,
+		media.Created,
+		media.Width,
+		media.Height,
+	)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	q = "INSERT INTO media_variants (media_id, key, content_type, width, height, size) VALUES ($1, $2, $3, $4, $5, $6);"
+	for _, v := range media.Variants {
+		if _, err := tx.ExecContext(ctx, q, media.Id, v.Key, v.ContentType, v.Width, v.Height, v.Size); err != nil {
+			return nil, types.NewErrInternalFailure(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &media, nil
+}
+
+func (repo *mediaPostgres) Delete(ctx context.Context, id string) (*types.Media, error) {
+	media, err := repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repo.db.ExecContext(ctx, "DELETE FROM media WHERE id=$1;", id); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return media, nil
+}
@@ -18,10 +18,22 @@ type Post interface {
 	FindAll(ctx context.Context) ([]types.Post, error)
 	GetPage(ctx context.Context, page, size int) (*types.Page[types.Post], error)
 	FindById(ctx context.Context, id string) (*types.Post, error)
+	// FindPublishedById is FindById for readers, drafts aren't found
+	FindPublishedById(ctx context.Context, id string) (*types.Post, error)
 	Create(ctx context.Context, post types.Post) (*types.Post, error)
 	Update(ctx context.Context, id string, post types.Post) (*types.Post, error)
 	Delete(ctx context.Context, id string) (*types.Post, error)
+	// Publish turns the draft id into a published post
+	Publish(ctx context.Context, id string) (*types.Post, error)
 	GetPageTime(ctx context.Context, time string, page, size int) (*types.Page[types.Post], error)
+	// GetPageAfter returns size posts following cursor (or the first page if
+	// cursor is nil) using keyset pagination
+	GetPageAfter(ctx context.Context, cursor *types.PostCursor, size int) (*types.Page[types.Post], error)
+	// GetPageByTag returns published posts tagged with tag, newest first
+	GetPageByTag(ctx context.Context, tag string, page, size int) (*types.Page[types.Post], error)
+	// GetIndex pages through published posts oldest first, so pages stay
+	// the same as posts are added. Only Id and Created are filled in.
+	GetIndex(ctx context.Context, page, size int) (*types.Page[types.Post], error)
 	Search(ctx context.Context, query string, page, size int) (*types.Page[types.Post], error)
 }
 
@@ -50,10 +62,39 @@ func (repo *postMock) FindById(ctx context.Context, id string) (*types.Post, err
 	return nil, types.ErrNotFound
 }
 
+func (repo *postMock) FindPublishedById(ctx context.Context, id string) (*types.Post, error) {
+	post, err := repo.FindById(ctx, id)
+	if err != nil || post.Draft {
+		return nil, types.ErrNotFound
+	}
+	return post, nil
+}
+
+func (repo *postMock) Publish(ctx context.Context, id string) (*types.Post, error) {
+	post, err := repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	post.Draft = false
+	return post, nil
+}
+
 func (repo *postMock) GetPageTime(ctx context.Context, time string, page, size int) (*types.Page[types.Post], error) {
 	return nil, nil
 }
 
+func (repo *postMock) GetPageAfter(ctx context.Context, cursor *types.PostCursor, size int) (*types.Page[types.Post], error) {
+	return nil, nil
+}
+
+func (repo *postMock) GetPageByTag(ctx context.Context, tag string, page, size int) (*types.Page[types.Post], error) {
+	return nil, nil
+}
+
+func (repo *postMock) GetIndex(ctx context.Context, page, size int) (*types.Page[types.Post], error) {
+	return nil, nil
+}
+
 func (repo *postMock) Create(ctx context.Context, post types.Post) (*types.Post, error) {
 	repo.posts = append(repo.posts, post)
 	return &post, nil
@@ -87,13 +128,44 @@ func NewPostPostgres() Post {
 	return repo
 }
 
+// postSQLite reuses the postgres queries, which are kept portable
+type postSQLite struct {
+	*postPostgres
+}
+
+func NewPostSQLite() Post {
+	return &postSQLite{
+		postPostgres: &postPostgres{db: data.SQLite()},
+	}
+}
+
+// scanPost reads a row selected with the post columns followed by the
+// comment count
+func scanPost(row interface{ Scan(...any) error }, post *types.Post) error {
+	var tags string
+	err := row.Scan(
+		&post.Id,
+		&post.Title,
+		&post.Content,
+		&post.Created,
+		&post.Pinned,
+		&post.Tweet,
+		&tags,
+		&post.Draft,
+		&post.TOC,
+		&post.Comments,
+	)
+	post.Tags = types.SplitTags(tags)
+	return err
+}
+
 func (repo *postPostgres) FindAll(ctx context.Context) ([]types.Post, error) {
 	posts := []types.Post{}
 	q := `
-		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, COUNT(c) comment_count 
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
 		FROM posts p LEFT JOIN comments c ON c.postid = p.id GROUP BY p.id ORDER BY p.pinned, p.created DESC;
 	`
-	rows, err := repo.db.QueryContext(ctx, q)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return posts, types.ErrNotFound
@@ -102,46 +174,32 @@ func (repo *postPostgres) FindAll(ctx context.Context) ([]types.Post, error) {
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var (
-			id            string
-			title         string
-			content       string
-			created       string
-			pinned        bool
-			tweet         bool
-			comment_count int
-		)
-		rows.Scan(&id, &title, &content, &created, &pinned, &tweet, &comment_count)
-		post := types.Post{
-			Id:       id,
-			Title:    title,
-			Content:  content,
-			Created:  created,
-			Pinned:   pinned,
-			Tweet:    tweet,
-			Comments: comment_count,
-		}
+		var post types.Post
+		scanPost(rows, &post)
 		posts = append(posts, post)
 	}
 	return posts, nil
 }
 
 func (repo *postPostgres) FindById(ctx context.Context, id string) (*types.Post, error) {
-	var post types.Post
 	q := `
-		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, COUNT(c) comment_count 
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
 		FROM posts p LEFT JOIN comments c ON c.postid = p.id GROUP BY p.id HAVING p.id = $1;
 	`
-	err := repo.db.QueryRowContext(ctx, q, id).Scan(
-		&post.Id,
-		&post.Title,
-		&post.Content,
-		&post.Created,
-		&post.Pinned,
-		&post.Tweet,
-		&post.Comments,
-	)
-	if err != nil {
+	return repo.findOne(ctx, q, id)
+}
+
+func (repo *postPostgres) FindPublishedById(ctx context.Context, id string) (*types.Post, error) {
+	q := `
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
+		FROM posts p LEFT JOIN comments c ON c.postid = p.id WHERE NOT p.draft GROUP BY p.id HAVING p.id = $1;
+	`
+	return repo.findOne(ctx, q, id)
+}
+
+func (repo *postPostgres) findOne(ctx context.Context, q string, args ...any) (*types.Post, error) {
+	var post types.Post
+	if err := scanPost(data.Conn(ctx, repo.db).QueryRowContext(ctx, q, args...), &post); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, types.ErrNotFound
 		}
@@ -150,9 +208,30 @@ func (repo *postPostgres) FindById(ctx context.Context, id string) (*types.Post,
 	return &post, nil
 }
 
+func (repo *postPostgres) Publish(ctx context.Context, id string) (*types.Post, error) {
+	res, err := data.Conn(ctx, repo.db).ExecContext(ctx, "UPDATE posts SET draft=$1 WHERE id=$2 AND draft;", false, id)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, types.ErrNotFound
+	}
+	return repo.FindById(ctx, id)
+}
+
 func (repo *postPostgres) Create(ctx context.Context, post types.Post) (*types.Post, error) {
-	q := "INSERT INTO posts (id, title, content, created, tweet) VALUES ($1, $2, $3, $4, $5);"
-	_, err := repo.db.ExecContext(ctx, q, post.Id, post.Title, post.Content, post.Created, post.Tweet)
+	q := "INSERT INTO posts (id, title, content, created, pinned, tweet, tags, draft, toc) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);"
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q,
+		post.Id,
+		post.Title,
+		post.Content,
+		post.Created,
+		post.Pinned,
+		post.Tweet,
+		types.JoinTags(post.Tags),
+		post.Draft,
+		post.TOC,
+	)
 	if err != nil {
 		return nil, types.NewErrInternalFailure(err)
 	}
@@ -161,7 +240,7 @@ func (repo *postPostgres) Create(ctx context.Context, post types.Post) (*types.P
 
 func (repo *postPostgres) Update(ctx context.Context, id string, post types.Post) (*types.Post, error) {
 	q := "UPDATE posts SET title=$1, content=$2, pinned=$3 WHERE id=$4;"
-	_, err := repo.db.ExecContext(ctx, q, post.Title, post.Content, post.Pinned, id)
+	_, err := data.Conn(ctx, repo.db).ExecContext(ctx, q, post.Title, post.Content, post.Pinned, id)
 	if err != nil {
 		return nil, types.NewErrInternalFailure(err)
 	}
@@ -174,7 +253,7 @@ func (repo *postPostgres) Delete(ctx context.Context, id string) (*types.Post, e
 		return nil, err
 	}
 	q := "DELETE FROM posts WHERE id=$1;"
-	_, err = repo.db.ExecContext(ctx, q, id)
+	_, err = data.Conn(ctx, repo.db).ExecContext(ctx, q, id)
 	if err != nil {
 		return nil, types.NewErrInternalFailure(err)
 	}
@@ -183,15 +262,19 @@ func (repo *postPostgres) Delete(ctx context.Context, id string) (*types.Post, e
 
 func (repo *postPostgres) GetPage(ctx context.Context, page, size int) (*types.Page[types.Post], error) {
 	posts := []types.Post{}
-	qcount := "SELECT COUNT(*) FROM posts;"
+	qcount := "SELECT COUNT(*) FROM posts WHERE NOT draft;"
 	var count int
-	repo.db.QueryRowContext(ctx, qcount).Scan(&count)
+	data.Conn(ctx, repo.db).QueryRowContext(ctx, qcount).Scan(&count)
 	hasNext := true
 	if (page-1)*size+size >= count {
 		hasNext = false
 	}
-	q := "SELECT * FROM posts ORDER BY pinned DESC, created DESC LIMIT $1 OFFSET $2;"
-	rows, err := repo.db.QueryContext(ctx, q, size, (page-1)*size)
+	q := `
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
+		FROM posts p LEFT JOIN comments c ON c.postid = p.id WHERE NOT p.draft GROUP BY p.id 
+		ORDER BY p.pinned DESC, p.created DESC LIMIT $1 OFFSET $2;
+	`
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, size, (page-1)*size)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return &types.Page[types.Post]{
@@ -213,7 +296,7 @@ func (repo *postPostgres) GetPage(ctx context.Context, page, size int) (*types.P
 	defer rows.Close()
 	for rows.Next() {
 		post := types.Post{}
-		rows.Scan(&post.Id, &post.Title, &post.Content, &post.Created, &post.Pinned, &post.Tweet)
+		scanPost(rows, &post)
 		posts = append(posts, post)
 	}
 	return &types.Page[types.Post]{
@@ -225,26 +308,91 @@ func (repo *postPostgres) GetPage(ctx context.Context, page, size int) (*types.P
 	}, nil
 }
 
+func (repo *postPostgres) GetPageByTag(ctx context.Context, tag string, page, size int) (*types.Page[types.Post], error) {
+	posts := []types.Post{}
+	// tags are stored as ",a,b,", see types.JoinTags
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(types.JoinTags([]string{tag}))
+	pattern := "%" + escaped + "%"
+	var count int
+	qcount := `SELECT COUNT(*) FROM posts WHERE tags LIKE $1 ESCAPE '\' AND NOT draft;`
+	if err := data.Conn(ctx, repo.db).QueryRowContext(ctx, qcount, pattern).Scan(&count); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	q := `
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
+		FROM posts p LEFT JOIN comments c ON c.postid = p.id WHERE p.tags LIKE $1 ESCAPE '\' AND NOT p.draft GROUP BY p.id 
+		ORDER BY p.created DESC LIMIT $2 OFFSET $3;
+	`
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, pattern, size, (page-1)*size)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		post := types.Post{}
+		scanPost(rows, &post)
+		posts = append(posts, post)
+	}
+	return &types.Page[types.Post]{
+		Content:  posts,
+		HasNext:  page*size < count,
+		Size:     size,
+		NextPage: page + 1,
+		Total:    count,
+	}, nil
+}
+
+func (repo *postPostgres) GetIndex(ctx context.Context, page, size int) (*types.Page[types.Post], error) {
+	posts := []types.Post{}
+	var count int
+	if err := data.Conn(ctx, repo.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM posts WHERE NOT draft;").Scan(&count); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	q := "SELECT id, created FROM posts WHERE NOT draft ORDER BY created, id LIMIT $1 OFFSET $2;"
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, size, (page-1)*size)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		post := types.Post{}
+		if err := rows.Scan(&post.Id, &post.Created); err != nil {
+			return nil, types.NewErrInternalFailure(err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &types.Page[types.Post]{
+		Content:  posts,
+		HasNext:  page*size < count,
+		Size:     size,
+		NextPage: page + 1,
+		Total:    count,
+	}, nil
+}
+
 func (repo *postPostgres) GetPageTime(
 	ctx context.Context,
 	time string,
 	page, size int,
 ) (*types.Page[types.Post], error) {
 	posts := []types.Post{}
-	qcount := "SELECT COUNT(*) FROM posts WHERE created <= $1;"
+	qcount := "SELECT COUNT(*) FROM posts WHERE created <= $1 AND NOT draft;"
 	var count int
-	repo.db.QueryRowContext(ctx, qcount, time).Scan(&count)
+	data.Conn(ctx, repo.db).QueryRowContext(ctx, qcount, time).Scan(&count)
 	hasNext := true
 	if (page-1)*size+size >= count {
 		hasNext = false
 	}
 	q := `
-		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, COUNT(c) comment_count 
-		FROM posts p LEFT JOIN comments c ON c.postid = p.id GROUP BY p.id HAVING p.created <= $3 
-		ORDER BY pinned DESC, created DESC LIMIT $1 OFFSET $2;
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
+		FROM posts p LEFT JOIN comments c ON c.postid = p.id WHERE NOT p.draft GROUP BY p.id HAVING p.created <= $3 
+		ORDER BY p.pinned DESC, p.created DESC LIMIT $1 OFFSET $2;
 	`
 	//q := "SELECT * FROM posts WHERE created <= $3 ORDER BY pinned DESC, created DESC LIMIT $1 OFFSET $2;"
-	rows, err := repo.db.QueryContext(ctx, q, size, (page-1)*size, time)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, size, (page-1)*size, time)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return &types.Page[types.Post]{
@@ -266,15 +414,7 @@ func (repo *postPostgres) GetPageTime(
 	defer rows.Close()
 	for rows.Next() {
 		post := types.Post{}
-		rows.Scan(
-			&post.Id,
-			&post.Title,
-			&post.Content,
-			&post.Created,
-			&post.Pinned,
-			&post.Tweet,
-			&post.Comments,
-		)
+		scanPost(rows, &post)
 		posts = append(posts, post)
 	}
 	return &types.Page[types.Post]{
@@ -286,6 +426,62 @@ func (repo *postPostgres) GetPageTime(
 	}, nil
 }
 
+func (repo *postPostgres) GetPageAfter(
+	ctx context.Context,
+	cursor *types.PostCursor,
+	size int,
+) (*types.Page[types.Post], error) {
+	posts := []types.Post{}
+	q := `
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
+		FROM posts p LEFT JOIN comments c ON c.postid = p.id WHERE NOT p.draft GROUP BY p.id 
+		ORDER BY p.pinned DESC, p.created DESC, p.id DESC LIMIT $1;
+	`
+	args := []any{size + 1}
+	if cursor != nil {
+		q = `
+			SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
+			FROM posts p LEFT JOIN comments c ON c.postid = p.id 
+			WHERE NOT p.draft AND (p.pinned, p.created, p.id) < ($2, $3, $4) GROUP BY p.id 
+			ORDER BY p.pinned DESC, p.created DESC, p.id DESC LIMIT $1;
+		`
+		args = append(args, cursor.Pinned, cursor.Created, cursor.Id)
+	}
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, q, args...)
+	if err != nil {
+		return &types.Page[types.Post]{
+			Content: posts,
+			HasNext: false,
+			Size:    size,
+		}, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		post := types.Post{}
+		scanPost(rows, &post)
+		posts = append(posts, post)
+	}
+	return postKeysetPage(posts, size), nil
+}
+
+func postKeysetPage(posts []types.Post, size int) *types.Page[types.Post] {
+	page := &types.Page[types.Post]{
+		Content: posts,
+		Size:    size,
+	}
+	if len(posts) > size {
+		page.Content = posts[:size]
+		last := page.Content[size-1]
+		page.HasNext = true
+		page.NextCursor = types.EncodeCursor(types.PostCursor{
+			Pinned:  last.Pinned,
+			Created: last.Created,
+			Id:      last.Id,
+		})
+	}
+	return page
+}
+
 func (repo *postPostgres) Search(ctx context.Context, query string, page, size int) (*types.Page[types.Post], error) {
 	return nil, nil
 }
@@ -307,23 +503,33 @@ func NewPostSearcherPostgres() PostSearcher {
 	return repo
 }
 
+type postSearcherSQLite struct {
+	*postSearcherPostgres
+}
+
+func NewPostSearcherSQLite() PostSearcher {
+	return &postSearcherSQLite{
+		postSearcherPostgres: &postSearcherPostgres{db: data.SQLite()},
+	}
+}
+
 func (repo *postSearcherPostgres) Search(ctx context.Context, q string, page, size int) (*types.Page[types.Post], error) {
 	q = strings.ToLower(q)
-	qcount := "SELECT COUNT(*) FROM posts WHERE LOWER(title) LIKE '%' || $1 || '%';"
+	qcount := "SELECT COUNT(*) FROM posts WHERE LOWER(title) LIKE '%' || $1 || '%' AND NOT draft;"
 	var count int
-	repo.db.QueryRowContext(ctx, qcount, q).Scan(&count)
+	data.Conn(ctx, repo.db).QueryRowContext(ctx, qcount, q).Scan(&count)
 	hasNext := true
 	if (page-1)*size+size >= count {
 		hasNext = false
 	}
 	posts := []types.Post{}
 	sqlq := `
-		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, COUNT(c) comment_count 
-		FROM posts p LEFT JOIN comments c ON c.postid = p.id GROUP BY p.id 
-		HAVING LOWER(p.title) LIKE '%' || $1 || '%' ORDER BY p.pinned DESC, p.created DESC OFFSET $2 LIMIT $3;
+		SELECT p.id, p.title, p.content, p.created, p.pinned, p.tweet, p.tags, p.draft, p.toc, COUNT(c.id) comment_count 
+		FROM posts p LEFT JOIN comments c ON c.postid = p.id WHERE NOT p.draft GROUP BY p.id 
+		HAVING LOWER(p.title) LIKE '%' || $1 || '%' ORDER BY p.pinned DESC, p.created DESC LIMIT $3 OFFSET $2;
 	`
 	//sqlq := "SELECT * FROM posts WHERE LOWER(title) LIKE '%' || $1 || '%' ORDER BY pinned DESC, created DESC OFFSET $2 LIMIT $3;"
-	rows, err := repo.db.QueryContext(ctx, sqlq, q, (page-1)*size, size)
+	rows, err := data.Conn(ctx, repo.db).QueryContext(ctx, sqlq, q, (page-1)*size, size)
 	if err != nil {
 		return &types.Page[types.Post]{
 			Content:  posts,
@@ -336,15 +542,7 @@ func (repo *postSearcherPostgres) Search(ctx context.Context, q string, page, si
 	defer rows.Close()
 	for rows.Next() {
 		post := types.Post{}
-		rows.Scan(
-			&post.Id,
-			&post.Title,
-			&post.Content,
-			&post.Created,
-			&post.Pinned,
-			&post.Tweet,
-			&post.Comments,
-		)
+		scanPost(rows, &post)
 		posts = append(posts, post)
 	}
 	return &types.Page[types.Post]{
@@ -0,0 +1,120 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/yosa12978/echoes/data"
+	"github.com/yosa12978/echoes/types"
+)
+
+// Subscription keeps the subscribers of the built-in WebSub hub. Times are
+// RFC 3339 strings in UTC, so they compare as text in both databases.
+type Subscription interface {
+	// Save adds a subscription, or renews the one with the same callback
+	// and topic
+	Save(ctx context.Context, sub types.Subscription) error
+	FindById(ctx context.Context, id string) (*types.Subscription, error)
+	// FindActive returns the subscriptions to topic that expire after now
+	FindActive(ctx context.Context, topic, now string) ([]types.Subscription, error)
+	Delete(ctx context.Context, callback, topic string) error
+	DeleteExpired(ctx context.Context, now string) error
+}
+
+type subscriptionPostgres struct {
+	db *sql.DB
+}
+
+func NewSubscriptionPostgres() Subscription {
+	return &subscriptionPostgres{db: data.Postgres()}
+}
+
+type subscriptionSQLite struct {
+	*subscriptionPostgres
+}
+
+func NewSubscriptionSQLite() Subscription {
+	return &subscriptionSQLite{
+		subscriptionPostgres: &subscriptionPostgres{db: data.SQLite()},
+	}
+}
+
+const subscriptionColumns = "id, callback, topic, secret, expires, created"
+
+func scanSubscription(row interface{ Scan(...any) error }, sub *types.Subscription) error {
+	return row.Scan(
+		&sub.Id,
+		&sub.Callback,
+		&sub.Topic,
+		&sub.Secret,
+		&sub.Expires,
+		&sub.Created,
+	)
+}
+
+func (repo *subscriptionPostgres) Save(ctx context.Context, sub types.Subscription) error {
+	q := "INSERT INTO websub_subscriptions (" + subscriptionColumns + ") VALUES ($1, $2, $3, $4, $5, $6) " +
+		"ON CONFLICT (callback, topic) DO UPDATE SET secret=excluded.secret, expires=excluded.expires;"
+	_, err := repo.db.ExecContext(ctx, q,
+		sub.Id,
+		sub.Callback,
+		sub.Topic,
+		sub.Secret,
+		sub.Expires,
+		sub.Created,
+	)
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (repo *subscriptionPostgres) FindById(ctx context.Context, id string) (*types.Subscription, error) {
+	var sub types.Subscription
+	q := "SELECT " + subscriptionColumns + " FROM websub_subscriptions WHERE id=$1;"
+	if err := scanSubscription(repo.db.QueryRowContext(ctx, q, id), &sub); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, types.ErrNotFound
+		}
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &sub, nil
+}
+
+func (repo *subscriptionPostgres) FindActive(ctx context.Context, topic, now string) ([]types.Subscription, error) {
+	subs := []types.Subscription{}
+	q := "SELECT " + subscriptionColumns + " FROM websub_subscriptions WHERE topic=$1 AND expires>$2 ORDER BY created;"
+	rows, err := repo.db.QueryContext(ctx, q, topic, now)
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sub types.Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return nil, types.NewErrInternalFailure(err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return subs, nil
+}
+
+func (repo *subscriptionPostgres) Delete(ctx context.Context, callback, topic string) error {
+	q := "DELETE FROM websub_subscriptions WHERE callback=$1 AND topic=$2;"
+	if _, err := repo.db.ExecContext(ctx, q, callback, topic); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
+
+func (repo *subscriptionPostgres) DeleteExpired(ctx context.Context, now string) error {
+	q := "DELETE FROM websub_subscriptions WHERE expires<=$1;"
+	if _, err := repo.db.ExecContext(ctx, q, now); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/yosa12978/echoes/data"
+	"github.com/yosa12978/echoes/types"
+)
+
+// Transactor runs changes spanning several repos in one transaction. The
+// post, comment, link and account repos take part in it when they are
+// given the context fn gets.
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) Transactor {
+	return &transactor{db: db}
+}
+
+// InTx commits the transaction when fn returns nil and rolls it back
+// otherwise
+func (t *transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	defer tx.Rollback()
+	if err := fn(data.WithTx(ctx, tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// mux is a ServeMux that remembers the patterns registered on it and on the
+// muxes mounted under it, so the OpenAPI document can be checked against
+// the routes that are really served
+type mux struct {
+	*http.ServeMux
+	prefix string
+	routes *[]string
+}
+
+func newMux() *mux {
+	return &mux{ServeMux: http.NewServeMux(), routes: &[]string{}}
+}
+
+func (m *mux) Handle(pattern string, handler http.Handler) {
+	m.record(pattern)
+	m.ServeMux.Handle(pattern, handler)
+}
+
+func (m *mux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.record(pattern)
+	m.ServeMux.HandleFunc(pattern, handler)
+}
+
+// record stores pattern as "METHOD /full/path"
+func (m *mux) record(pattern string) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		method, path = "", pattern
+	}
+	*m.routes = append(*m.routes, method+" "+m.prefix+path)
+}
+
+// mount serves sub under prefix. The mount itself isn't a route, the
+// routes of sub are recorded with prefix in front.
+func (m *mux) mount(prefix string) *mux {
+	sub := &mux{ServeMux: http.NewServeMux(), prefix: m.prefix + prefix, routes: m.routes}
+	m.ServeMux.Handle(prefix+"/", http.StripPrefix(prefix, sub))
+	return sub
+}
+
+// Routes returns every recorded route as "METHOD /path"
+func (m *mux) Routes() []string {
+	return append([]string(nil), *m.routes...)
+}
+
+// fallbackMethods are tried on requests no route matches, to tell a path
+// served under other methods from one that isn't served at all
+var fallbackMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// fallback answers the requests no route matches. Paths served under other
+// methods get methodNotAllowed with an Allow header, anything else gets
+// notFound. It isn't a route, so it isn't recorded.
+func (m *mux) fallback(notFound, methodNotAllowed http.HandlerFunc) {
+	m.ServeMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range fallbackMethods {
+			probe := *r
+			probe.Method = method
+			if _, pattern := m.ServeMux.Handler(&probe); pattern != "/" {
+				allowed = append(allowed, method)
+			}
+		}
+		if len(allowed) == 0 {
+			notFound(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		methodNotAllowed(w, r)
+	})
+}
@@ -0,0 +1,430 @@
+package router
+
+import (
+	"github.com/yosa12978/echoes/openapi"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+)
+
+// sessionCookie is how admins are recognized, see session.StartSession
+const sessionCookie = "echoes_session"
+
+// spec builds the OpenAPI document of every route in addRoutes. The
+// routes are checked against it by TestRoutesAreDocumented.
+func spec() *openapi.Document {
+	d := openapi.New(openapi.Info{
+		Title: "echoes",
+		Description: "The JSON API lives under /api/v1. The other routes under /api " +
+			"return html fragments for the pages, which load them with htmx.",
+		Version: "1",
+	})
+	d.Tags = []openapi.Tag{
+		{Name: "posts", Description: "JSON API"},
+		{Name: "comments", Description: "JSON API"},
+		{Name: "links", Description: "JSON API"},
+		{Name: "announce", Description: "JSON API"},
+		{Name: "profile", Description: "JSON API"},
+		{Name: "fragments", Description: "html fragments for the pages"},
+		{Name: "admin", Description: "admin html fragments and files"},
+		{Name: "pages"},
+		{Name: "feeds"},
+		{Name: "crawlers", Description: "sitemaps, robots.txt and preview images"},
+		{Name: "meta", Description: "the server itself and this document"},
+	}
+	d.Components.SecuritySchemes["session"] = openapi.SecurityScheme{
+		Type:        "apiKey",
+		In:          "cookie",
+		Name:        sessionCookie,
+		Description: "set by POST /api/login",
+	}
+	addSpecV1(d)
+	addSpecFragments(d)
+	addSpecPages(d)
+	return d
+}
+
+var (
+	html        = openapi.Content("html fragment", "text/html", nil)
+	htmlPage    = openapi.Content("page", "text/html", nil)
+	notFound    = openapi.Empty("not found")
+	sessionAuth = []map[string][]string{{"session": {}}}
+	pageParams  = []openapi.Parameter{
+		openapi.Query("cursor", "string", "next_cursor of the previous page"),
+		openapi.Query("page", "integer", "page number, from 1, instead of a cursor"),
+		openapi.Query("limit", "integer", "items per page, 20 by default and 100 at most"),
+	}
+)
+
+// admin marks an html route only admins can use. Others get a 404, so the
+// route looks like it doesn't exist.
+func admin(op openapi.Operation) openapi.Operation {
+	op.Security = sessionAuth
+	op.Responses["404"] = openapi.Empty("not found, or not logged in as an admin")
+	op.Responses["403"] = openapi.Empty("the account isn't an admin")
+	return op
+}
+
+func addSpecV1(d *openapi.Document) {
+	apiError := d.Schema(types.APIError{})
+	failures := func(codes ...string) map[string]openapi.Response {
+		descriptions := map[string]string{
+			"400": "malformed request",
+			"404": "not found",
+			"422": "invalid fields, listed in problems",
+		}
+		res := map[string]openapi.Response{
+			"500": openapi.JSON("internal failure", apiError),
+		}
+		for _, code := range codes {
+			res[code] = openapi.JSON(descriptions[code], apiError)
+		}
+		return res
+	}
+	with := func(res map[string]openapi.Response, code string, r openapi.Response) map[string]openapi.Response {
+		res[code] = r
+		return res
+	}
+	v1Admin := func(op openapi.Operation) openapi.Operation {
+		op.Security = sessionAuth
+		op.Responses["401"] = openapi.JSON("not logged in", apiError)
+		op.Responses["403"] = openapi.JSON("the account isn't an admin", apiError)
+		return op
+	}
+	deleted := openapi.Empty("deleted")
+
+	post := d.Schema(types.APIData[types.APIPost]{})
+	d.Add("GET /api/v1/posts", openapi.Operation{
+		Tags:    []string{"posts"},
+		Summary: "List posts",
+		Description: "Newest first, pinned posts on top. Pages follow a cursor unless page, " +
+			"query or tag is set.",
+		OperationID: "listPosts",
+		Parameters: append(append([]openapi.Parameter{}, pageParams...),
+			openapi.Query("query", "string", "full text search"),
+			openapi.Query("tag", "string", "only posts with this tag"),
+		),
+		Responses: with(failures("400"), "200", openapi.JSON("page of posts", d.Schema(types.APIPage[types.APIPost]{}))),
+	})
+	d.Add("GET /api/v1/posts/{id}", openapi.Operation{
+		Tags:        []string{"posts"},
+		Summary:     "Get a post",
+		OperationID: "getPost",
+		Responses:   with(failures("404"), "200", openapi.JSON("post", post)),
+	})
+	d.Add("POST /api/v1/posts", v1Admin(openapi.Operation{
+		Tags:        []string{"posts"},
+		Summary:     "Create a post",
+		Description: "tweet and toc are turned on by any non empty string.",
+		OperationID: "createPost",
+		RequestBody: openapi.Body("application/json", d.Schema(types.PostCreateDto{})),
+		Responses:   with(failures("400", "422"), "201", openapi.JSON("created post", post)),
+	}))
+	d.Add("DELETE /api/v1/posts/{id}", v1Admin(openapi.Operation{
+		Tags:        []string{"posts"},
+		Summary:     "Delete a post",
+		OperationID: "deletePost",
+		Responses:   with(failures("404"), "204", deleted),
+	}))
+	d.Add("POST /api/v1/posts/{id}/pin", v1Admin(openapi.Operation{
+		Tags:        []string{"posts"},
+		Summary:     "Pin or unpin a post",
+		OperationID: "pinPost",
+		Responses:   with(failures("404"), "200", openapi.JSON("post with its new pinned state", post)),
+	}))
+
+	comment := d.Schema(types.APIData[types.APIComment]{})
+	d.Add("GET /api/v1/posts/{id}/comments", openapi.Operation{
+		Tags:        []string{"comments"},
+		Summary:     "List the comments of a post",
+		Description: "Newest first. Pages follow a cursor unless page is set.",
+		OperationID: "listPostComments",
+		Parameters:  pageParams,
+		Responses:   with(failures("400", "404"), "200", openapi.JSON("page of comments", d.Schema(types.APIPage[types.APIComment]{}))),
+	})
+	d.Add("POST /api/v1/posts/{id}/comments", openapi.Operation{
+		Tags:        []string{"comments"},
+		Summary:     "Comment on a post",
+		OperationID: "createComment",
+		RequestBody: openapi.Body("application/json", d.Schema(types.CommentCreateDto{})),
+		Responses:   with(failures("400", "404", "422"), "201", openapi.JSON("created comment", comment)),
+	})
+	d.Add("GET /api/v1/comments/{id}", openapi.Operation{
+		Tags:        []string{"comments"},
+		Summary:     "Get a comment",
+		OperationID: "getComment",
+		Responses:   with(failures("404"), "200", openapi.JSON("comment", comment)),
+	})
+	d.Add("DELETE /api/v1/comments/{id}", v1Admin(openapi.Operation{
+		Tags:        []string{"comments"},
+		Summary:     "Delete a comment",
+		OperationID: "deleteComment",
+		Responses:   with(failures("404"), "204", deleted),
+	}))
+
+	link := d.Schema(types.APIData[types.APILink]{})
+	d.Add("GET /api/v1/links", openapi.Operation{
+		Tags:        []string{"links"},
+		Summary:     "List every link",
+		OperationID: "listLinks",
+		Responses:   with(failures(), "200", openapi.JSON("links in order of their place", d.Schema(types.APIData[[]types.APILink]{}))),
+	})
+	d.Add("GET /api/v1/links/{id}", openapi.Operation{
+		Tags:        []string{"links"},
+		Summary:     "Get a link",
+		OperationID: "getLink",
+		Responses:   with(failures("404"), "200", openapi.JSON("link", link)),
+	})
+	d.Add("POST /api/v1/links", v1Admin(openapi.Operation{
+		Tags:        []string{"links"},
+		Summary:     "Create a link",
+		Description: "place is a number sent as a string, 1 when it's empty.",
+		OperationID: "createLink",
+		RequestBody: openapi.Body("application/json", d.Schema(types.LinkCreateDto{})),
+		Responses:   with(failures("400", "422"), "201", openapi.JSON("created link", link)),
+	}))
+	d.Add("DELETE /api/v1/links/{id}", v1Admin(openapi.Operation{
+		Tags:        []string{"links"},
+		Summary:     "Delete a link",
+		OperationID: "deleteLink",
+		Responses:   with(failures("404"), "204", deleted),
+	}))
+
+	announce := d.Schema(types.APIData[types.APIAnnounce]{})
+	d.Add("GET /api/v1/announce", openapi.Operation{
+		Tags:        []string{"announce"},
+		Summary:     "Get the announcement",
+		OperationID: "getAnnounce",
+		Responses:   with(failures("404"), "200", openapi.JSON("announcement", announce)),
+	})
+	d.Add("PUT /api/v1/announce", v1Admin(openapi.Operation{
+		Tags:        []string{"announce"},
+		Summary:     "Replace the announcement",
+		OperationID: "putAnnounce",
+		RequestBody: openapi.Body("application/json", d.Schema(types.AnnounceCreateDto{})),
+		Responses:   with(failures("400", "422"), "200", openapi.JSON("new announcement", announce)),
+	}))
+	d.Add("DELETE /api/v1/announce", v1Admin(openapi.Operation{
+		Tags:        []string{"announce"},
+		Summary:     "Remove the announcement",
+		OperationID: "deleteAnnounce",
+		Responses:   with(failures(), "204", deleted),
+	}))
+
+	d.Add("GET /api/v1/profile", openapi.Operation{
+		Tags:        []string{"profile"},
+		Summary:     "Get the profile of the author",
+		OperationID: "getProfile",
+		Responses:   with(failures(), "200", openapi.JSON("profile", d.Schema(types.APIData[types.Profile]{}))),
+	})
+}
+
+func addSpecFragments(d *openapi.Document) {
+	fragment := func(summary string, params ...openapi.Parameter) openapi.Operation {
+		return openapi.Operation{
+			Tags:       []string{"fragments"},
+			Summary:    summary,
+			Parameters: params,
+			Responses:  map[string]openapi.Response{"200": html},
+		}
+	}
+	adminFragment := func(summary string, params ...openapi.Parameter) openapi.Operation {
+		op := fragment(summary, params...)
+		op.Tags = []string{"admin"}
+		return admin(op)
+	}
+	withBody := func(op openapi.Operation, body *openapi.RequestBody) openapi.Operation {
+		op.RequestBody = body
+		return op
+	}
+	formId := openapi.Body("application/x-www-form-urlencoded", openapi.Object("id"))
+
+	d.Add("GET /api/links", fragment("Links"))
+	portal := fragment("Follow a link")
+	portal.Responses = map[string]openapi.Response{"301": openapi.Empty("redirect to the url of the link, or home when it's gone")}
+	d.Add("GET /api/portal/{id}", portal)
+	d.Add("GET /api/links-admin", adminFragment("Links with delete buttons"))
+	d.Add("POST /api/links", withBody(adminFragment("Create a link"), openapi.Body("application/json", d.Schema(types.LinkCreateDto{}))))
+	d.Add("DELETE /api/links/{id}", adminFragment("Delete a link"))
+
+	d.Add("GET /api/posts", fragment("Posts", append(append([]openapi.Parameter{}, pageParams...),
+		openapi.Query("query", "string", "full text search"))...))
+	d.Add("GET /api/posts/{id}", fragment("A post, drafts only for admins"))
+	d.Add("POST /api/posts", withBody(adminFragment("Create a post"), openapi.Body("application/json", d.Schema(types.PostCreateDto{}))))
+	d.Add("DELETE /api/posts", withBody(adminFragment("Delete a post"), formId))
+	d.Add("PATCH /api/post-pin", withBody(adminFragment("Pin or unpin a post"), openapi.Body("application/json", openapi.Object("id"))))
+	d.Add("PATCH /api/post-publish", withBody(adminFragment("Publish a draft"), openapi.Body("application/json", openapi.Object("id"))))
+
+	d.Add("GET /api/profile", fragment("Profile"))
+
+	login := fragment("Log in")
+	login.RequestBody = openapi.Body("application/json", openapi.Object("username", "password"))
+	login.Description = "Starts a session and answers with an HX-Redirect to /admin, or an alert."
+	d.Add("POST /api/login", login)
+	logout := fragment("Log out")
+	logout.Responses = map[string]openapi.Response{
+		"200": openapi.Empty("logged out, with an HX-Redirect to the home page"),
+		"401": openapi.Empty("there was no session"),
+	}
+	d.Add("GET /api/logout", logout)
+
+	d.Add("GET /api/announce", fragment("Announcement"))
+	d.Add("POST /api/announce", withBody(adminFragment("Replace the announcement"), openapi.Body("application/json", d.Schema(types.AnnounceCreateDto{}))))
+	d.Add("DELETE /api/announce", adminFragment("Remove the announcement"))
+
+	postId := openapi.Query("postId", "string", "id of the post")
+	postId.Required = true
+	d.Add("GET /api/comments", fragment("Comments of a post", append([]openapi.Parameter{postId}, pageParams[:2]...)...))
+	d.Add("POST /api/comments", withBody(fragment("Comment on a post", postId), openapi.Body("application/json", d.Schema(types.CommentCreateDto{}))))
+	d.Add("DELETE /api/comments", withBody(adminFragment("Delete a comment"), formId))
+	count := fragment("Number of comments of a post")
+	count.Responses = map[string]openapi.Response{"200": openapi.Content("the number", "text/plain", &openapi.Schema{Type: "integer"})}
+	d.Add("GET /api/comments-count/{id}", count)
+
+	d.Add("GET /api/jobs-failed", adminFragment("Dead lettered background jobs"))
+	d.Add("POST /api/jobs/{id}/retry", adminFragment("Retry a dead lettered job"))
+	d.Add("DELETE /api/jobs/{id}", adminFragment("Drop a dead lettered job"))
+
+	backup := adminFragment("Download a backup")
+	backup.Responses["200"] = openapi.Content("zip archive", "application/zip", &openapi.Schema{Type: "string", Format: "binary"})
+	d.Add("GET /api/backup", backup)
+	binary := &openapi.Schema{Type: "string", Format: "binary"}
+	archive := &openapi.Schema{Type: "object", Required: []string{"archive"}, Properties: map[string]*openapi.Schema{
+		"archive": binary,
+		"mode":    {Type: "string", Description: "merge, the default, or replace"},
+	}}
+	d.Add("POST /api/backup", withBody(adminFragment("Restore a backup"), openapi.Body("multipart/form-data", archive)))
+
+	d.Add("GET /api/media", adminFragment("Media library", openapi.Query("page", "integer", "page number, from 1")))
+	file := &openapi.Schema{Type: "object", Required: []string{"file"}, Properties: map[string]*openapi.Schema{"file": binary}}
+	d.Add("POST /api/media", withBody(adminFragment("Upload a file"), openapi.Body("multipart/form-data", file)))
+	d.Add("DELETE /api/media/{id}", adminFragment("Delete an upload"))
+
+	d.Add("GET /api/openapi.json", openapi.Operation{
+		Tags:      []string{"meta"},
+		Summary:   "This document",
+		Responses: map[string]openapi.Response{"200": openapi.JSON("OpenAPI 3 document", nil)},
+	})
+	d.Add("GET /api/docs", openapi.Operation{
+		Tags:      []string{"meta"},
+		Summary:   "This document for people",
+		Responses: map[string]openapi.Response{"200": htmlPage},
+	})
+}
+
+func addSpecPages(d *openapi.Document) {
+	page := func(tag, summary string) openapi.Operation {
+		return openapi.Operation{
+			Tags:      []string{tag},
+			Summary:   summary,
+			Responses: map[string]openapi.Response{"200": htmlPage},
+		}
+	}
+	d.Add("GET /{$}", page("pages", "Home page"))
+	d.Add("GET /blog", page("pages", "Blog"))
+	post := page("pages", "Post")
+	post.Responses["404"] = notFound
+	d.Add("GET /posts/{id}", post)
+	d.Add("GET /login", page("pages", "Login page"))
+	d.Add("GET /admin", admin(page("admin", "Admin page")))
+	d.Add("GET /admin/media", admin(page("admin", "Media library page")))
+
+	media := openapi.Operation{
+		Tags:    []string{"pages"},
+		Summary: "Uploaded file",
+		Responses: map[string]openapi.Response{
+			"200": openapi.Content("the file", "application/octet-stream", &openapi.Schema{Type: "string", Format: "binary"}),
+			"404": notFound,
+		},
+	}
+	d.Add("GET /media/{key...}", media)
+	assets := media
+	assets.Summary = "Bundled asset"
+	d.Add("GET /assets/{path...}", assets)
+	highlight := page("pages", "Stylesheet of highlighted code")
+	highlight.Responses["200"] = openapi.Content("stylesheet", "text/css", nil)
+	d.Add("GET /assets/css/highlight.css", highlight)
+
+	// /feed negotiates the format, the others have it in the path
+	feedTypes := map[string]string{"/feed": ""}
+	for _, format := range services.FeedFormats {
+		feedTypes[format.Path()] = format.ContentType()
+	}
+	prefixes := map[string]string{
+		"":                     "posts",
+		"/tags/{tag}":          "posts with a tag",
+		"/comments":            "the latest comments",
+		"/posts/{id}/comments": "the comments of a post",
+	}
+	for prefix, of := range prefixes {
+		for suffix, contentType := range feedTypes {
+			op := openapi.Operation{
+				Tags:    []string{"feeds"},
+				Summary: "Feed of " + of,
+				Responses: map[string]openapi.Response{
+					"304": openapi.Empty("not modified"),
+					"404": notFound,
+				},
+			}
+			if contentType == "" {
+				op.Description = "In the format the Accept header asks for, Atom by default."
+				op.Responses["200"] = openapi.Response{Description: "feed", Content: map[string]openapi.MediaType{}}
+				for _, format := range services.FeedFormats {
+					op.Responses["200"].Content[format.ContentType()] = openapi.MediaType{}
+				}
+			} else {
+				op.Responses["200"] = openapi.Content("feed", contentType, nil)
+			}
+			d.Add("GET "+prefix+suffix, op)
+		}
+	}
+	d.Add("POST /websub", openapi.Operation{
+		Tags:        []string{"feeds"},
+		Summary:     "Subscribe to a feed at the built-in WebSub hub",
+		RequestBody: openapi.Body("application/x-www-form-urlencoded", openapi.Object("hub.mode", "hub.callback", "hub.topic")),
+		Responses: map[string]openapi.Response{
+			"202": openapi.Empty("accepted, the subscriber is asked to confirm"),
+			"400": openapi.Empty("invalid request"),
+			"404": openapi.Empty("the built-in hub is off"),
+		},
+	})
+
+	sitemap := openapi.Content("sitemap", "application/xml", nil)
+	d.Add("GET /sitemap.xml", openapi.Operation{
+		Tags:      []string{"crawlers"},
+		Summary:   "Sitemap, or an index of numbered sitemaps on big sites",
+		Responses: map[string]openapi.Response{"200": sitemap},
+	})
+	d.Add("GET /sitemaps/{page}", openapi.Operation{
+		Tags:      []string{"crawlers"},
+		Summary:   "Numbered sitemap, the page is like 2.xml",
+		Responses: map[string]openapi.Response{"200": sitemap, "404": notFound},
+	})
+	d.Add("GET /robots.txt", openapi.Operation{
+		Tags:      []string{"crawlers"},
+		Summary:   "robots.txt",
+		Responses: map[string]openapi.Response{"200": openapi.Content("rules", "text/plain", nil)},
+	})
+	d.Add("GET /posts/{id}/og.png", openapi.Operation{
+		Tags:    []string{"crawlers"},
+		Summary: "Generated preview image of a post",
+		Responses: map[string]openapi.Response{
+			"200": openapi.Content("1200x630 image", "image/png", &openapi.Schema{Type: "string", Format: "binary"}),
+			"404": notFound,
+		},
+	})
+
+	d.Add("GET /health", openapi.Operation{
+		Tags:    []string{"meta"},
+		Summary: "Health of the database and redis",
+		Responses: map[string]openapi.Response{
+			"200": openapi.Content("healthy", "text/plain", nil),
+			"500": openapi.Content("what's unhealthy", "text/plain", nil),
+		},
+	})
+	vars := admin(openapi.Operation{
+		Tags:      []string{"meta"},
+		Summary:   "expvar metrics",
+		Responses: map[string]openapi.Response{"200": openapi.JSON("metrics", nil)},
+	})
+	d.Add("GET /debug/vars", vars)
+}
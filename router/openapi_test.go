@@ -0,0 +1,72 @@
+package router
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yosa12978/echoes/openapi"
+)
+
+func TestRoutesAreDocumented(t *testing.T) {
+	m := newMux()
+	addRoutes(m, defaultOptions())
+	doc := spec()
+
+	served := map[string]bool{}
+	for _, route := range m.Routes() {
+		method, pattern, _ := strings.Cut(route, " ")
+		if method == "" {
+			t.Errorf("route %q takes any method, give it one", route)
+			continue
+		}
+		if !doc.Has(route) {
+			t.Errorf("route %q isn't in the OpenAPI document, add it in spec()", route)
+		}
+		served[method+" "+openapi.Path(pattern)] = true
+	}
+	for _, route := range doc.Routes() {
+		if !served[route] {
+			t.Errorf("%q is documented but not served", route)
+		}
+	}
+}
+
+func TestSpecReferencesExist(t *testing.T) {
+	doc := spec()
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, value := range v {
+				if ref, ok := value.(string); ok && key == "$ref" {
+					refs = append(refs, ref)
+				}
+				walk(value)
+			}
+		case []any:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	var parsed any
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	walk(parsed)
+	if len(refs) == 0 {
+		t.Fatal("the document refers to no schemas")
+	}
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("%s refers to a missing schema", ref)
+		}
+	}
+}
@@ -3,7 +3,9 @@ package router
 import (
 	"os"
 
+	"github.com/yosa12978/echoes/jobs"
 	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/markdown"
 	"github.com/yosa12978/echoes/services"
 )
 
@@ -12,12 +14,19 @@ type optionFunc func(*options)
 type options struct {
 	accountService  services.Account
 	announceService services.Announce
+	backupService   services.Backup
 	commentService  services.Comment
 	feedService     services.Feed
 	healthService   services.HealthService
 	postService     services.Post
 	profileService  services.Profile
 	linkService     services.Link
+	mediaService    services.Media
+	websubService   services.WebSub
+	sitemapService  services.Sitemap
+	previewService  services.Preview
+	jobQueue        jobs.Queue
+	markdown        *markdown.Renderer
 	logger          logging.Logger
 }
 
@@ -88,3 +97,45 @@ func WithPostService(s services.Post) optionFunc {
 		o.postService = s
 	}
 }
+
+func WithJobQueue(q jobs.Queue) optionFunc {
+	return func(o *options) {
+		o.jobQueue = q
+	}
+}
+
+func WithBackupService(backupService services.Backup) optionFunc {
+	return func(o *options) {
+		o.backupService = backupService
+	}
+}
+
+func WithMarkdown(renderer *markdown.Renderer) optionFunc {
+	return func(o *options) {
+		o.markdown = renderer
+	}
+}
+
+func WithMediaService(s services.Media) optionFunc {
+	return func(o *options) {
+		o.mediaService = s
+	}
+}
+
+func WithWebSubService(s services.WebSub) optionFunc {
+	return func(o *options) {
+		o.websubService = s
+	}
+}
+
+func WithSitemapService(s services.Sitemap) optionFunc {
+	return func(o *options) {
+		o.sitemapService = s
+	}
+}
+
+func WithPreviewService(s services.Preview) optionFunc {
+	return func(o *options) {
+		o.previewService = s
+	}
+}
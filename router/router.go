@@ -1,21 +1,25 @@
 package router
 
 import (
+	"expvar"
 	"net/http"
 
 	"github.com/yosa12978/echoes/endpoints"
+	v1 "github.com/yosa12978/echoes/endpoints/v1"
 	"github.com/yosa12978/echoes/middleware"
+	"github.com/yosa12978/echoes/services"
 	"github.com/yosa12978/echoes/session"
+	"github.com/yosa12978/echoes/types"
 	"github.com/yosa12978/echoes/utils"
 )
 
 func New(opts ...optionFunc) http.Handler {
 	options := newOptions(opts...)
-	router := http.NewServeMux()
+	router := newMux()
 	addRoutes(router, options)
 	var handler http.Handler = router
 	handler = middleware.Pipeline(
-		router,
+		router.ServeMux,
 		middleware.Logger(options.logger),
 		middleware.StripSlash,
 		middleware.Recovery(options.logger),
@@ -23,27 +27,36 @@ func New(opts ...optionFunc) http.Handler {
 	return handler
 }
 
-func addRoutes(r *http.ServeMux, options options) {
-	apiRouter := http.NewServeMux()
+func addRoutes(r *mux, options options) {
+	apiRouter := r.mount("/api")
 
 	addLinkRoutes(apiRouter, options)
 	addPostRoutes(apiRouter, options)
 	addProfileRoutes(apiRouter, options)
 	addFeedRoutes(r, options)
+	addWebSubRoutes(r, options)
+	addSitemapRoutes(r, options)
 	addAccountRoutes(apiRouter, options)
 	addAnnounceRoutes(apiRouter, options)
 	addHealthRoutes(r, options)
+	addMetricsRoutes(r)
 	addCommentRoutes(apiRouter, options)
-	addViewRoutes(r)
+	addJobRoutes(apiRouter, options)
+	addBackupRoutes(apiRouter, options)
+	addMediaRoutes(r, apiRouter, options)
+	addViewRoutes(r, options)
+	addAPIv1Routes(r, options)
+	addDocsRoutes(apiRouter)
 
-	r.Handle("/api/", http.StripPrefix("/api", apiRouter))
+	// generated from the highlighting style, so it isn't a file in assets
+	r.HandleFunc("GET /assets/css/highlight.css", endpoints.HighlightCSS(options.markdown))
 
-	r.Handle("/assets/", http.StripPrefix("/assets",
+	r.Handle("GET /assets/{path...}", http.StripPrefix("/assets",
 		http.FileServer(http.Dir("./assets/")),
 	))
 }
 
-func addLinkRoutes(router *http.ServeMux, options options) {
+func addLinkRoutes(router *mux, options options) {
 	router.Handle("GET /links",
 		endpoints.GetLinks(options.logger, options.linkService))
 
@@ -65,7 +78,7 @@ func addLinkRoutes(router *http.ServeMux, options options) {
 	)
 }
 
-func addPostRoutes(router *http.ServeMux, options options) {
+func addPostRoutes(router *mux, options options) {
 	router.Handle("GET /posts",
 		endpoints.GetPosts(options.logger, options.postService))
 
@@ -89,9 +102,15 @@ func addPostRoutes(router *http.ServeMux, options options) {
 			endpoints.PinPost(options.logger, options.postService),
 		),
 	)
+
+	router.Handle("PATCH /post-publish",
+		middleware.Admin(
+			endpoints.PublishPost(options.logger, options.postService),
+		),
+	)
 }
 
-func addCommentRoutes(router *http.ServeMux, options options) {
+func addCommentRoutes(router *mux, options options) {
 	router.Handle("GET /comments",
 		endpoints.GetPostComments(options.logger, options.commentService))
 
@@ -108,18 +127,46 @@ func addCommentRoutes(router *http.ServeMux, options options) {
 		endpoints.GetCommentCount(options.logger, options.commentService))
 }
 
-func addFeedRoutes(router *http.ServeMux, options options) {
-	router.HandleFunc("GET /feed", endpoints.GetFeed(options.feedService))
+func addFeedRoutes(router *mux, options options) {
+	// each feed is served at <prefix>/feed, which negotiates the format, and
+	// at <prefix>/feed.atom, .xml and .json
+	feeds := map[string]func(services.Feed, services.FeedFormat) http.HandlerFunc{
+		"":                     endpoints.GetFeed,
+		"/tags/{tag}":          endpoints.GetTagFeed,
+		"/comments":            endpoints.GetCommentsFeed,
+		"/posts/{id}/comments": endpoints.GetCommentsFeed,
+	}
+	for prefix, handler := range feeds {
+		router.HandleFunc("GET "+prefix+"/feed", handler(options.feedService, ""))
+		for _, format := range services.FeedFormats {
+			router.HandleFunc("GET "+prefix+format.Path(), handler(options.feedService, format))
+		}
+	}
+}
+
+func addWebSubRoutes(router *mux, options options) {
+	router.Handle("POST "+services.WebSubHubPath,
+		endpoints.WebSubHub(options.logger, options.websubService))
 }
 
-func addAccountRoutes(router *http.ServeMux, options options) {
+func addSitemapRoutes(router *mux, options options) {
+	router.Handle("GET /sitemap.xml",
+		endpoints.GetSitemap(options.logger, options.sitemapService))
+
+	router.Handle("GET /sitemaps/{page}",
+		endpoints.GetSitemapPage(options.logger, options.sitemapService))
+
+	router.Handle("GET /robots.txt", endpoints.Robots())
+}
+
+func addAccountRoutes(router *mux, options options) {
 	router.Handle("POST /login",
 		endpoints.Login(options.logger, options.accountService))
 
 	router.Handle("GET /logout", endpoints.Logout())
 }
 
-func addAnnounceRoutes(router *http.ServeMux, options options) {
+func addAnnounceRoutes(router *mux, options options) {
 	router.Handle("GET /announce",
 		endpoints.GetAnnounce(options.logger, options.announceService))
 
@@ -135,31 +182,158 @@ func addAnnounceRoutes(router *http.ServeMux, options options) {
 	)
 }
 
-func addProfileRoutes(router *http.ServeMux, options options) {
+func addProfileRoutes(router *mux, options options) {
 	router.Handle("GET /profile",
 		endpoints.GetProfile(options.logger, options.profileService))
 }
 
-func addHealthRoutes(router *http.ServeMux, options options) {
+func addHealthRoutes(router *mux, options options) {
 	router.HandleFunc("GET /health", endpoints.Healthcheck(options.healthService))
 }
 
-func addViewRoutes(router *http.ServeMux) {
+func addJobRoutes(router *mux, options options) {
+	router.Handle("GET /jobs-failed",
+		middleware.Admin(
+			endpoints.GetFailedJobs(options.logger, options.jobQueue),
+		),
+	)
+
+	router.Handle("POST /jobs/{id}/retry",
+		middleware.Admin(
+			endpoints.RetryJob(options.logger, options.jobQueue),
+		),
+	)
+
+	router.Handle("DELETE /jobs/{id}",
+		middleware.Admin(
+			endpoints.DeleteJob(options.logger, options.jobQueue),
+		),
+	)
+}
+
+func addBackupRoutes(router *mux, options options) {
+	router.Handle("GET /backup",
+		middleware.Admin(
+			endpoints.ExportBackup(options.logger, options.backupService),
+		),
+	)
+
+	router.Handle("POST /backup",
+		middleware.Admin(
+			endpoints.ImportBackup(options.logger, options.backupService),
+		),
+	)
+}
+
+func addMediaRoutes(router, apiRouter *mux, options options) {
+	router.HandleFunc("GET /media/{key...}",
+		endpoints.ServeMedia(options.logger, options.mediaService))
+
+	apiRouter.Handle("GET /media",
+		middleware.Admin(
+			endpoints.GetMedia(options.logger, options.mediaService),
+		),
+	)
+
+	apiRouter.Handle("POST /media",
+		middleware.Admin(
+			endpoints.UploadMedia(options.logger, options.mediaService),
+		),
+	)
+
+	apiRouter.Handle("DELETE /media/{id}",
+		middleware.Admin(
+			endpoints.DeleteMedia(options.logger, options.mediaService),
+		),
+	)
+}
+
+func addMetricsRoutes(router *mux) {
+	router.Handle("GET /debug/vars", middleware.Admin(expvar.Handler()))
+}
+
+// addAPIv1Routes serves the JSON API, the routes under /api return html
+// fragments for the pages
+func addAPIv1Routes(router *mux, options options) {
+	api := router.mount("/api/v1")
+
+	api.Handle("GET /posts",
+		v1.GetPosts(options.logger, options.postService))
+	api.Handle("GET /posts/{id}",
+		v1.GetPost(options.logger, options.postService))
+	api.Handle("POST /posts", middleware.APIAdmin(
+		v1.CreatePost(options.logger, options.postService),
+	))
+	api.Handle("DELETE /posts/{id}", middleware.APIAdmin(
+		v1.DeletePost(options.logger, options.postService),
+	))
+	api.Handle("POST /posts/{id}/pin", middleware.APIAdmin(
+		v1.PinPost(options.logger, options.postService),
+	))
+
+	api.Handle("GET /posts/{id}/comments",
+		v1.GetPostComments(options.logger, options.postService, options.commentService))
+	api.Handle("POST /posts/{id}/comments",
+		v1.CreateComment(options.logger, options.postService, options.commentService))
+	api.Handle("GET /comments/{id}",
+		v1.GetComment(options.logger, options.commentService))
+	api.Handle("DELETE /comments/{id}", middleware.APIAdmin(
+		v1.DeleteComment(options.logger, options.commentService),
+	))
+
+	api.Handle("GET /links",
+		v1.GetLinks(options.logger, options.linkService))
+	api.Handle("GET /links/{id}",
+		v1.GetLink(options.logger, options.linkService))
+	api.Handle("POST /links", middleware.APIAdmin(
+		v1.CreateLink(options.logger, options.linkService),
+	))
+	api.Handle("DELETE /links/{id}", middleware.APIAdmin(
+		v1.DeleteLink(options.logger, options.linkService),
+	))
+
+	api.Handle("GET /announce",
+		v1.GetAnnounce(options.logger, options.announceService))
+	api.Handle("PUT /announce", middleware.APIAdmin(
+		v1.PutAnnounce(options.logger, options.announceService),
+	))
+	api.Handle("DELETE /announce", middleware.APIAdmin(
+		v1.DeleteAnnounce(options.logger, options.announceService),
+	))
+
+	api.Handle("GET /profile",
+		v1.GetProfile(options.logger, options.profileService))
+
+	api.fallback(v1.NotFound, v1.MethodNotAllowed)
+}
+
+func addDocsRoutes(router *mux) {
+	doc := spec()
+	router.Handle("GET /openapi.json", endpoints.OpenAPI(doc))
+	router.Handle("GET /docs", endpoints.APIDocs(doc))
+}
+
+func addViewRoutes(router *mux, options options) {
 	router.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
-		if err := utils.RenderView(w, "index", "", nil); err != nil {
+		if err := utils.RenderView(w, "index", "", types.Meta{URL: utils.SiteURL(r, "/")}, nil); err != nil {
 			http.Error(w, err.Error(), 500)
 		}
 	})
 
-	router.HandleFunc("GET /posts/{id}", func(w http.ResponseWriter, r *http.Request) {
-		idStr := r.PathValue("id")
-		if err := utils.RenderView(w, "post", "blog", idStr); err != nil {
+	router.Handle("GET /posts/{id}",
+		endpoints.PostView(options.logger, options.postService))
+
+	router.Handle("GET /posts/{id}/og.png",
+		endpoints.PostPreview(options.logger, options.previewService))
+
+	router.Handle("GET /admin", middleware.Admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := utils.RenderView(w, "admin", "admin", types.Meta{}, nil); err != nil {
 			http.Error(w, err.Error(), 500)
 		}
-	})
+	})))
 
-	router.Handle("GET /admin", middleware.Admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if err := utils.RenderView(w, "admin", "admin", nil); err != nil {
+	router.Handle("GET /admin/media", middleware.Admin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := utils.RenderView(w, "media", "media", types.Meta{}, nil); err != nil {
 			http.Error(w, err.Error(), 500)
 		}
 	})))
@@ -170,13 +344,13 @@ func addViewRoutes(router *http.ServeMux) {
 			http.Redirect(w, r, "/admin", http.StatusMovedPermanently)
 			return
 		}
-		if err := utils.RenderView(w, "login", "login", nil); err != nil {
+		if err := utils.RenderView(w, "login", "login", types.Meta{}, nil); err != nil {
 			http.Error(w, err.Error(), 500)
 		}
 	})
 
 	router.HandleFunc("GET /blog", func(w http.ResponseWriter, r *http.Request) {
-		if err := utils.RenderView(w, "blog", "blog", nil); err != nil {
+		if err := utils.RenderView(w, "blog", "blog", types.Meta{URL: utils.SiteURL(r, "/blog")}, nil); err != nil {
 			http.Error(w, err.Error(), 500)
 		}
 	})
@@ -17,6 +17,11 @@ import (
 type Account interface {
 	GetByCredentials(ctx context.Context, username, password string) (*types.Account, error)
 	CreateAccount(ctx context.Context, username, password string, isAdmin bool) (*types.Account, error)
+	GetAccounts(ctx context.Context) ([]types.Account, error)
+	DeleteAccount(ctx context.Context, username string) (*types.Account, error)
+	ResetPassword(ctx context.Context, username, password string) error
+	// Seed creates the root account or resets its password to the one
+	// from config
 	Seed(ctx context.Context) error
 }
 
@@ -46,6 +51,9 @@ func (a *account) GetByCredentials(ctx context.Context, username, password strin
 }
 
 func (a *account) CreateAccount(ctx context.Context, username, password string, isAdmin bool) (*types.Account, error) {
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
 	if a.isUsernameTaken(ctx, username) {
 		return nil, types.NewErrBadRequest(errors.New("username is already taken"))
 	}
@@ -63,54 +71,76 @@ func (a *account) CreateAccount(ctx context.Context, username, password string,
 		IsAdmin:  isAdmin,
 		Salt:     salt,
 	}
-	res, err := a.accountRepo.Create(ctx, acc)
-	if err != nil {
+	if _, err := a.accountRepo.Create(ctx, acc); err != nil {
 		return nil, err
 	}
-	return res, nil
+	return &acc, nil
 }
 
-// refactor this
-func (a *account) Seed(ctx context.Context) error {
-	cfg := config.Get()
-	usr, err := a.accountRepo.FindByUsername(ctx, "root")
+func (a *account) GetAccounts(ctx context.Context) ([]types.Account, error) {
+	return a.accountRepo.FindAll(ctx)
+}
+
+func (a *account) DeleteAccount(ctx context.Context, username string) (*types.Account, error) {
+	if strings.ToLower(username) == "root" {
+		return nil, types.NewErrBadRequest(errors.New("root account can't be deleted"))
+	}
+	acc, err := a.accountRepo.FindByUsername(ctx, username)
 	if err != nil {
-		if _, err := a.CreateAccount(
-			ctx,
-			"root",
-			cfg.Server.RootPass,
-			true,
-		); err != nil {
-			return err
+		if errors.Is(err, types.ErrNotFound) {
+			return nil, types.NewErrNotFound(fmt.Errorf("account %s not found", username))
 		}
+		return nil, err
+	}
+	if err := a.accountRepo.Delete(ctx, acc.Id); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
 
+func (a *account) ResetPassword(ctx context.Context, username, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
 	}
-	if usr != nil {
-		if !utils.CheckPasswordHash(cfg.Server.RootPass+usr.Salt, usr.Password) {
-			a.changeRootPassword(ctx, usr.Id, cfg.Server.RootPass)
+	acc, err := a.accountRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return types.NewErrNotFound(fmt.Errorf("account %s not found", username))
 		}
+		return err
 	}
-	return nil
+	salt := uuid.NewString()
+	passwordHash, err := utils.HashPassword(password + salt)
+	if err != nil {
+		return types.NewErrInternalFailure(err)
+	}
+	acc.Password = passwordHash
+	acc.Salt = salt
+	return a.accountRepo.Update(ctx, acc.Id, *acc)
 }
 
-// remove userId
-func (a *account) changeRootPassword(ctx context.Context, userId, newPassword string) error {
-	fmt.Println("changing password")
-	if strings.Contains(newPassword, " ") {
+func validatePassword(password string) error {
+	if strings.Contains(password, " ") {
 		return types.NewErrBadRequest(errors.New("password can't contain spaces"))
 	}
-	if len(newPassword) < 4 {
+	if len(password) < 4 {
 		return types.NewErrBadRequest(errors.New("length of your password can't be less then 4 characters"))
 	}
-	salt := uuid.NewString()
-	passwordHash, _ := utils.HashPassword(newPassword + salt)
-	if err := a.accountRepo.Update(ctx, userId, types.Account{
-		Username: "root",
-		IsAdmin:  true,
-		Password: passwordHash,
-		Salt:     salt,
-	}); err != nil {
+	return nil
+}
+
+func (a *account) Seed(ctx context.Context) error {
+	cfg := config.Get()
+	usr, err := a.accountRepo.FindByUsername(ctx, "root")
+	if err != nil {
+		if !errors.Is(err, types.ErrNotFound) {
+			return err
+		}
+		_, err := a.CreateAccount(ctx, "root", cfg.Server.RootPass, true)
 		return err
 	}
+	if !utils.CheckPasswordHash(cfg.Server.RootPass+usr.Salt, usr.Password) {
+		return a.ResetPassword(ctx, usr.Username, cfg.Server.RootPass)
+	}
 	return nil
 }
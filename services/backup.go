@@ -0,0 +1,549 @@
+package services
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yosa12978/echoes/cache"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+type Backup interface {
+	// Export writes a zip archive with the whole site to w
+	Export(ctx context.Context, w io.Writer) (*types.BackupManifest, error)
+	// Import restores an archive written by Export. mode is either
+	// types.ImportMerge or types.ImportReplace. A replace runs in one
+	// transaction and stops at the first record that fails, leaving the
+	// site as it was.
+	Import(ctx context.Context, r io.ReaderAt, size int64, mode string) (*types.BackupReport, error)
+}
+
+const (
+	backupManifest = "manifest.json"
+	backupPosts    = "posts.ndjson"
+	backupComments = "comments.ndjson"
+	backupLinks    = "links.ndjson"
+	backupAccounts = "accounts.ndjson"
+	backupAnnounce = "announce.json"
+)
+
+type postRecord struct {
+	Id      string   `json:"id"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Created string   `json:"created"`
+	Pinned  bool     `json:"pinned"`
+	Tweet   bool     `json:"tweet"`
+	Tags    []string `json:"tags,omitempty"`
+	Draft   bool     `json:"draft,omitempty"`
+	TOC     bool     `json:"toc,omitempty"`
+}
+
+type commentRecord struct {
+	Id      string `json:"id"`
+	PostId  string `json:"post_id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Content string `json:"content"`
+	Created string `json:"created"`
+}
+
+type linkRecord struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Icon    string `json:"icon"`
+	Place   int    `json:"place"`
+	Created string `json:"created"`
+}
+
+// accountRecord leaves out password hashes and salts on purpose. Imported
+// accounts get a random password that has to be reset.
+type accountRecord struct {
+	Id       string `json:"id"`
+	Username string `json:"username"`
+	IsAdmin  bool   `json:"is_admin"`
+	Created  string `json:"created"`
+}
+
+type announceRecord struct {
+	Content string `json:"content"`
+	Date    string `json:"date"`
+}
+
+type backup struct {
+	postRepo     repos.Post
+	commentRepo  repos.Comment
+	linkRepo     repos.Link
+	accountRepo  repos.Account
+	announceRepo repos.Announce
+	tx           repos.Transactor
+	caches       cache.Flusher
+	postService  Post
+	logger       logging.Logger
+}
+
+func NewBackup(
+	postRepo repos.Post,
+	commentRepo repos.Comment,
+	linkRepo repos.Link,
+	accountRepo repos.Account,
+	announceRepo repos.Announce,
+	tx repos.Transactor,
+	caches cache.Flusher,
+	postService Post,
+	logger logging.Logger,
+) Backup {
+	return &backup{
+		postRepo:     postRepo,
+		commentRepo:  commentRepo,
+		linkRepo:     linkRepo,
+		accountRepo:  accountRepo,
+		announceRepo: announceRepo,
+		tx:           tx,
+		caches:       caches,
+		postService:  postService,
+		logger:       logger,
+	}
+}
+
+func (s *backup) Export(ctx context.Context, w io.Writer) (*types.BackupManifest, error) {
+	posts, err := s.postRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := s.commentRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.linkRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.accountRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	announce, err := s.announceRepo.Get(ctx)
+	if err != nil && !errors.Is(err, types.ErrNotFound) {
+		return nil, err
+	}
+
+	postRecords := make([]postRecord, len(posts))
+	for i, p := range posts {
+		postRecords[i] = postRecord{p.Id, p.Title, p.Content, p.Created, p.Pinned, p.Tweet, p.Tags, p.Draft, p.TOC}
+	}
+	commentRecords := make([]commentRecord, len(comments))
+	for i, c := range comments {
+		commentRecords[i] = commentRecord{c.Id, c.PostId, c.Name, c.Email, c.Content, c.Created}
+	}
+	linkRecords := make([]linkRecord, len(links))
+	for i, l := range links {
+		linkRecords[i] = linkRecord{l.Id, l.Name, l.URL, l.Icon, l.Place, l.Created}
+	}
+	accountRecords := make([]accountRecord, len(accounts))
+	for i, a := range accounts {
+		accountRecords[i] = accountRecord{a.Id, a.Username, a.IsAdmin, a.Created}
+	}
+
+	manifest := types.BackupManifest{
+		SchemaVersion: types.BackupSchemaVersion,
+		Created:       time.Now().UTC().Format(time.RFC3339),
+		Counts: map[string]int{
+			"posts":    len(postRecords),
+			"comments": len(commentRecords),
+			"links":    len(linkRecords),
+			"accounts": len(accountRecords),
+			"announce": 0,
+		},
+	}
+
+	zw := zip.NewWriter(w)
+	if err := writeNDJSON(zw, backupPosts, postRecords); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	if err := writeNDJSON(zw, backupComments, commentRecords); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	if err := writeNDJSON(zw, backupLinks, linkRecords); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	if err := writeNDJSON(zw, backupAccounts, accountRecords); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	if announce != nil {
+		manifest.Counts["announce"] = 1
+		if err := writeJSON(zw, backupAnnounce, announceRecord{announce.Content, announce.Date}); err != nil {
+			return nil, types.NewErrInternalFailure(err)
+		}
+	}
+	if err := writeJSON(zw, backupManifest, manifest); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &manifest, nil
+}
+
+func (s *backup) Import(ctx context.Context, r io.ReaderAt, size int64, mode string) (*types.BackupReport, error) {
+	if mode != types.ImportMerge && mode != types.ImportReplace {
+		return nil, types.NewErrBadRequest(fmt.Errorf("unknown import mode %q", mode))
+	}
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, types.NewErrBadRequest(fmt.Errorf("not a zip archive: %w", err))
+	}
+	var manifest types.BackupManifest
+	found, err := readJSON(zr, backupManifest, &manifest)
+	if err != nil || !found {
+		return nil, types.NewErrBadRequest(errors.New("archive has no valid manifest"))
+	}
+	if manifest.SchemaVersion < 1 || manifest.SchemaVersion > types.BackupSchemaVersion {
+		return nil, types.NewErrBadRequest(
+			fmt.Errorf("unsupported backup schema version %d", manifest.SchemaVersion))
+	}
+
+	posts, err := readNDJSON[postRecord](zr, backupPosts)
+	if err != nil {
+		return nil, types.NewErrBadRequest(err)
+	}
+	comments, err := readNDJSON[commentRecord](zr, backupComments)
+	if err != nil {
+		return nil, types.NewErrBadRequest(err)
+	}
+	links, err := readNDJSON[linkRecord](zr, backupLinks)
+	if err != nil {
+		return nil, types.NewErrBadRequest(err)
+	}
+	accounts, err := readNDJSON[accountRecord](zr, backupAccounts)
+	if err != nil {
+		return nil, types.NewErrBadRequest(err)
+	}
+	var announce announceRecord
+	hasAnnounce, err := readJSON(zr, backupAnnounce, &announce)
+	if err != nil {
+		return nil, types.NewErrBadRequest(err)
+	}
+
+	report := types.NewBackupReport(mode)
+	restore := func(ctx context.Context) error {
+		if mode == types.ImportReplace {
+			if err := s.clear(ctx); err != nil {
+				return err
+			}
+		}
+		postIds, err := s.importPosts(ctx, posts, report)
+		if err != nil {
+			return err
+		}
+		if err := s.importComments(ctx, comments, postIds, report); err != nil {
+			return err
+		}
+		if err := s.importLinks(ctx, links, report); err != nil {
+			return err
+		}
+		return s.importAccounts(ctx, accounts, report)
+	}
+	if mode == types.ImportReplace {
+		err = s.tx.InTx(ctx, restore)
+	} else {
+		err = restore(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the announcement isn't in the database, it's replaced once the rest
+	// is in place
+	if mode == types.ImportReplace {
+		if err := s.announceRepo.Delete(ctx); err != nil {
+			s.logger.Error("failed to delete the announcement", "error", err.Error())
+		}
+	}
+	if hasAnnounce {
+		s.importAnnounce(ctx, announce, mode, report)
+	}
+
+	if _, err := s.caches.Flush(ctx); err != nil {
+		s.logger.Error("failed to flush caches after import", "error", err.Error())
+	}
+	if _, err := s.postService.Reindex(ctx); err != nil {
+		s.logger.Error("failed to reindex posts after import", "error", err.Error())
+	}
+	return report, nil
+}
+
+// clear removes the posts and links replaced by an import. Comments go
+// away with their posts.
+func (s *backup) clear(ctx context.Context) error {
+	posts, err := s.postRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range posts {
+		if _, err := s.postRepo.Delete(ctx, p.Id); err != nil {
+			return err
+		}
+	}
+	links, err := s.linkRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		if _, err := s.linkRepo.Delete(ctx, l.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failed records a record that couldn't be imported. A merge goes on with
+// the next one, a replace is stopped and rolled back.
+func failed(report *types.BackupReport, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if report.Mode == types.ImportReplace {
+		return types.NewErrBadRequest(fmt.Errorf("import stopped, nothing was replaced: %s", msg))
+	}
+	report.Errors = append(report.Errors, msg)
+	return nil
+}
+
+// importPosts returns a map from archived post ids to the ids the posts
+// ended up with
+func (s *backup) importPosts(ctx context.Context, records []postRecord, report *types.BackupReport) (map[string]string, error) {
+	ids := make(map[string]string, len(records))
+	for _, rec := range records {
+		post := types.Post{
+			Id:      rec.Id,
+			Title:   rec.Title,
+			Content: rec.Content,
+			Created: rec.Created,
+			Pinned:  rec.Pinned,
+			Tweet:   rec.Tweet,
+			Tags:    rec.Tags,
+			Draft:   rec.Draft,
+			TOC:     rec.TOC,
+		}
+		if existing, err := s.postRepo.FindById(ctx, rec.Id); err == nil {
+			if existing.Title == rec.Title && existing.Created == rec.Created {
+				ids[rec.Id] = existing.Id
+				report.Skipped["posts"]++
+				continue
+			}
+			post.Id = uuid.NewString()
+			report.Remapped["posts"]++
+		}
+		if post.Id == "" {
+			post.Id = uuid.NewString()
+		}
+		if _, err := s.postRepo.Create(ctx, post); err != nil {
+			if err := failed(report, "post %s: %s", rec.Id, err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ids[rec.Id] = post.Id
+		report.Imported["posts"]++
+	}
+	return ids, nil
+}
+
+func (s *backup) importComments(ctx context.Context, records []commentRecord, postIds map[string]string, report *types.BackupReport) error {
+	for _, rec := range records {
+		postId, ok := postIds[rec.PostId]
+		if !ok {
+			if _, err := s.postRepo.FindById(ctx, rec.PostId); err != nil {
+				if err := failed(report, "comment %s: post %s doesn't exist", rec.Id, rec.PostId); err != nil {
+					return err
+				}
+				continue
+			}
+			postId = rec.PostId
+		}
+		comment := types.Comment{
+			Id:      rec.Id,
+			Email:   rec.Email,
+			Name:    rec.Name,
+			Content: rec.Content,
+			Created: rec.Created,
+			PostId:  postId,
+		}
+		if existing, err := s.commentRepo.FindById(ctx, rec.Id); err == nil {
+			if existing.Content == rec.Content && existing.Created == rec.Created && existing.PostId == postId {
+				report.Skipped["comments"]++
+				continue
+			}
+			comment.Id = uuid.NewString()
+			report.Remapped["comments"]++
+		}
+		if comment.Id == "" {
+			comment.Id = uuid.NewString()
+		}
+		if _, err := s.commentRepo.Create(ctx, comment); err != nil {
+			if err := failed(report, "comment %s: %s", rec.Id, err); err != nil {
+				return err
+			}
+			continue
+		}
+		report.Imported["comments"]++
+	}
+	return nil
+}
+
+func (s *backup) importLinks(ctx context.Context, records []linkRecord, report *types.BackupReport) error {
+	for _, rec := range records {
+		link := types.Link{
+			Id:      rec.Id,
+			Name:    rec.Name,
+			URL:     rec.URL,
+			Created: rec.Created,
+			Icon:    rec.Icon,
+			Place:   rec.Place,
+		}
+		if existing, err := s.linkRepo.FindById(ctx, rec.Id); err == nil {
+			if existing.Name == rec.Name && existing.URL == rec.URL {
+				report.Skipped["links"]++
+				continue
+			}
+			link.Id = uuid.NewString()
+			report.Remapped["links"]++
+		}
+		if link.Id == "" {
+			link.Id = uuid.NewString()
+		}
+		if _, err := s.linkRepo.Create(ctx, link); err != nil {
+			if err := failed(report, "link %s: %s", rec.Id, err); err != nil {
+				return err
+			}
+			continue
+		}
+		report.Imported["links"]++
+	}
+	return nil
+}
+
+// importAccounts only adds accounts whose username is free. Archives don't
+// carry passwords, so new accounts get a random one.
+func (s *backup) importAccounts(ctx context.Context, records []accountRecord, report *types.BackupReport) error {
+	for _, rec := range records {
+		if _, err := s.accountRepo.FindByUsername(ctx, rec.Username); err == nil {
+			report.Skipped["accounts"]++
+			continue
+		}
+		salt := uuid.NewString()
+		hash, err := utils.HashPassword(uuid.NewString() + salt)
+		if err != nil {
+			if err := failed(report, "account %s: %s", rec.Username, err); err != nil {
+				return err
+			}
+			continue
+		}
+		acc := types.Account{
+			Id:       rec.Id,
+			Username: rec.Username,
+			Password: hash,
+			Salt:     salt,
+			Created:  rec.Created,
+			IsAdmin:  rec.IsAdmin,
+		}
+		if _, err := s.accountRepo.FindById(ctx, rec.Id); err == nil || acc.Id == "" {
+			acc.Id = uuid.NewString()
+			report.Remapped["accounts"]++
+		}
+		if _, err := s.accountRepo.Create(ctx, acc); err != nil {
+			if err := failed(report, "account %s: %s", rec.Username, err); err != nil {
+				return err
+			}
+			continue
+		}
+		report.Imported["accounts"]++
+	}
+	return nil
+}
+
+func (s *backup) importAnnounce(ctx context.Context, rec announceRecord, mode string, report *types.BackupReport) {
+	if mode == types.ImportMerge {
+		if current, err := s.announceRepo.Get(ctx); err == nil && current != nil {
+			report.Skipped["announce"]++
+			return
+		}
+	}
+	if err := s.announceRepo.Create(ctx, rec.Content); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("announce: %s", err))
+		return
+	}
+	report.Imported["announce"]++
+}
+
+func writeJSON(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeNDJSON[T any](zw *zip.Writer, name string, items []T) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readJSON decodes file name into dest and reports whether the file exists
+func readJSON(zr *zip.Reader, name string, dest any) (bool, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return false, nil
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(dest); err != nil {
+		return true, fmt.Errorf("%s: %w", name, err)
+	}
+	return true, nil
+}
+
+// readNDJSON decodes one record per line. A missing file means no records.
+func readNDJSON[T any](zr *zip.Reader, name string) ([]T, error) {
+	items := []T{}
+	f, err := zr.Open(name)
+	if err != nil {
+		return items, nil
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var item T
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return items, nil
+}
@@ -10,14 +10,24 @@ import (
 	"github.com/yosa12978/echoes/cache"
 	"github.com/yosa12978/echoes/logging"
 	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/tasks"
 	"github.com/yosa12978/echoes/types"
 )
 
 type Comment interface {
 	GetPostComments(ctx context.Context, postId string, page, size int) (*types.Page[types.Comment], error)
+	// GetPostCommentsAfter pages through comments of a post with an opaque
+	// cursor taken from types.Page.NextCursor
+	GetPostCommentsAfter(ctx context.Context, postId, cursor string, size int) (*types.Page[types.Comment], error)
 	GetCommentById(ctx context.Context, commentId string) (*types.Comment, error)
+	// GetLatestComments returns the newest size comments on published
+	// posts, with the titles of those posts
+	GetLatestComments(ctx context.Context, size int) ([]types.LatestComment, error)
 	CreateComment(ctx context.Context, postId, name, email, content string) (*types.Comment, error)
 	DeleteComment(ctx context.Context, commentId string) (*types.Comment, error)
+	// ImportComment stores an existing comment keeping its creation date.
+	// Comments without an id get a new one.
+	ImportComment(ctx context.Context, comment types.Comment) (*types.Comment, error)
 	GetCommentsCount(ctx context.Context, postId string) (int, error)
 	Seed(ctx context.Context) error
 }
@@ -27,14 +37,22 @@ type comment struct {
 	postService Post
 	cache       cache.Comment
 	logger      logging.Logger
+	tasks       tasks.Runner
 }
 
-func NewComment(commentRepo repos.Comment, postService Post, cache cache.Comment, logger logging.Logger) Comment {
+func NewComment(commentRepo repos.Comment, postService Post, cache cache.Comment, logger logging.Logger, runner tasks.Runner) Comment {
 	return &comment{
 		commentRepo: commentRepo,
 		postService: postService,
 		cache:       cache,
 		logger:      logger,
+		tasks:       runner,
+	}
+}
+
+func (s *comment) background(name string, task tasks.Task) {
+	if err := s.tasks.Submit(name, task); err != nil {
+		s.logger.Error(err.Error())
 	}
 }
 
@@ -42,7 +60,7 @@ func (s *comment) GetPostComments(ctx context.Context, postId string, page, size
 	// if _, err := s.postService.GetPostById(ctx, postId); err != nil {
 	// 	return nil, err
 	// }
-	commentsFromCache, version, err := s.cache.GetPostComments(ctx, postId, page)
+	commentsFromCache, version, err := s.cache.GetPostComments(ctx, postId, page, size)
 	if err != nil {
 		if errors.Is(err, types.ErrInternalFailure) {
 			s.logger.Error(err.Error())
@@ -52,22 +70,33 @@ func (s *comment) GetPostComments(ctx context.Context, postId string, page, size
 		return commentsFromCache, nil
 	}
 
-	t := time.UnixMicro(version).Format(time.RFC3339)
+	t := time.UnixMicro(version).UTC().Format(time.RFC3339)
 	commentsPaged, err := s.commentRepo.GetPageTime(ctx, t, postId, page, size)
 	if err != nil {
 		s.logger.Error(err.Error())
 		return nil, err
 	}
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.cache.AddPostComments(timeout, postId, page, *commentsPaged); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	s.background("comments.cache_page", func(ctx context.Context) error {
+		return s.cache.AddPostComments(ctx, postId, page, *commentsPaged)
+	})
 	return commentsPaged, nil
 }
 
+func (s *comment) GetPostCommentsAfter(ctx context.Context, postId, cursor string, size int) (*types.Page[types.Comment], error) {
+	if size < 1 {
+		return nil, types.NewErrBadRequest(errors.New("size must be positive"))
+	}
+	after, err := types.DecodeCursor[types.CommentCursor](cursor)
+	if err != nil {
+		return nil, err
+	}
+	return s.commentRepo.GetPageAfter(ctx, postId, after, size)
+}
+
+func (s *comment) GetLatestComments(ctx context.Context, size int) ([]types.LatestComment, error) {
+	return s.commentRepo.GetLatest(ctx, size)
+}
+
 func (s *comment) GetCommentById(ctx context.Context, commentId string) (*types.Comment, error) {
 	commentFromCache, err := s.cache.GetCommentById(ctx, commentId)
 	if err == nil {
@@ -82,13 +111,10 @@ func (s *comment) GetCommentById(ctx context.Context, commentId string) (*types.
 		return nil, err
 	}
 
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.cache.AddComment(timeout, *comment); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	cached := *comment
+	s.background("comments.cache_comment", func(ctx context.Context) error {
+		return s.cache.AddComment(ctx, cached)
+	})
 
 	return comment, nil
 }
@@ -115,30 +141,41 @@ func (s *comment) CreateComment(ctx context.Context, postId, name, email, conten
 		PostId:  postId,
 	}
 
-	go func() { // i don't like this. refactor
-		timeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := s.cache.AddComment(timeout, comm); err != nil {
-			s.logger.Error(err.Error())
-		}
-		if _, err := s.cache.RefreshPagination(timeout, postId); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	s.background("comments.cache_comment", func(ctx context.Context) error {
+		return s.cache.AddComment(ctx, comm)
+	})
+	s.background("comments.refresh_pagination", func(ctx context.Context) error {
+		_, err := s.cache.RefreshPagination(ctx, postId)
+		return err
+	})
 	return s.commentRepo.Create(ctx, comm)
 }
 
 func (s *comment) DeleteComment(ctx context.Context, commentId string) (*types.Comment, error) {
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		if err := s.cache.DeleteComment(timeout, commentId); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	s.background("comments.cache_delete", func(ctx context.Context) error {
+		return s.cache.DeleteComment(ctx, commentId)
+	})
 	return s.commentRepo.Delete(ctx, commentId)
 }
 
+func (s *comment) ImportComment(ctx context.Context, comment types.Comment) (*types.Comment, error) {
+	if comment.Id == "" {
+		comment.Id = uuid.NewString()
+	}
+	if comment.Created == "" {
+		comment.Created = time.Now().UTC().Format(time.RFC3339)
+	}
+	created, err := s.commentRepo.Create(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+	s.background("comments.refresh_pagination", func(ctx context.Context) error {
+		_, err := s.cache.RefreshPagination(ctx, comment.PostId)
+		return err
+	})
+	return created, nil
+}
+
 func (s *comment) Seed(ctx context.Context) error {
 	for i := 0; i < 60; i++ {
 		time.Sleep(1 * time.Second)
@@ -163,12 +200,8 @@ func (s *comment) GetCommentsCount(ctx context.Context, postId string) (int, err
 	if err != nil {
 		return 0, err
 	}
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.cache.SetCommentsCount(timeout, postId, count); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	s.background("comments.cache_count", func(ctx context.Context) error {
+		return s.cache.SetCommentsCount(ctx, postId, count)
+	})
 	return count, nil
 }
@@ -1,46 +1,358 @@
 package services
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"html"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/gorilla/feeds"
+	"github.com/yosa12978/echoes/cache"
 	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/tasks"
 	"github.com/yosa12978/echoes/types"
-	"github.com/yuin/goldmark"
 )
 
+// FeedFormat is a syndication format the feed can be generated in
+type FeedFormat string
+
+const (
+	FeedAtom FeedFormat = "atom"
+	FeedRSS  FeedFormat = "rss"
+	FeedJSON FeedFormat = "json"
+)
+
+// FeedFormats are the supported formats, the first one is the default
+var FeedFormats = []FeedFormat{FeedAtom, FeedRSS, FeedJSON}
+
+func (f FeedFormat) ContentType() string {
+	switch f {
+	case FeedRSS:
+		return "application/rss+xml"
+	case FeedJSON:
+		return "application/feed+json"
+	}
+	return "application/atom+xml"
+}
+
+// Path is where the feed is served in f
+func (f FeedFormat) Path() string {
+	switch f {
+	case FeedRSS:
+		return "/feed.xml"
+	case FeedJSON:
+		return "/feed.json"
+	}
+	return "/feed.atom"
+}
+
 type Feed interface {
-	GenerateFeed(ctx context.Context) (string, error)
+	// GenerateFeed returns the latest posts in format. Feeds are cached
+	// until the posts change.
+	GenerateFeed(ctx context.Context, format FeedFormat) (*types.Feed, error)
+	// GenerateTagFeed returns the latest posts tagged with tag
+	GenerateTagFeed(ctx context.Context, tag string, format FeedFormat) (*types.Feed, error)
+	// GenerateCommentsFeed returns the latest comments on the post with
+	// postId, or on every post when postId is empty
+	GenerateCommentsFeed(ctx context.Context, postId string, format FeedFormat) (*types.Feed, error)
+	// Topics are the urls of the feeds a post with tags appears in, the
+	// ones WebSub subscribers are told about when the post changes
+	Topics(tags []string) []string
+	// Topic generates the post feed at the topic url. Anything else, like
+	// a comments feed or a url of another site, isn't found.
+	Topic(ctx context.Context, topic string) (*types.Feed, error)
 }
 
+// feedSize is the number of entries in a feed
+const feedSize = 30
+
 type feed struct {
-	postService Post
+	postService    Post
+	commentService Comment
+	markdown       Markdown
+	cache          cache.Versioned[types.Feed]
+	logger         logging.Logger
+	tasks          tasks.Runner
 }
 
-func NewFeedService(postService Post) Feed {
+func NewFeedService(postService Post, commentService Comment, markdown Markdown, cache cache.Versioned[types.Feed], logger logging.Logger, runner tasks.Runner) Feed {
 	return &feed{
-		postService: postService,
+		postService:    postService,
+		commentService: commentService,
+		markdown:       markdown,
+		cache:          cache,
+		logger:         logger,
+		tasks:          runner,
 	}
 }
 
-// TODO: refactor
-func (f *feed) GenerateFeed(ctx context.Context) (string, error) {
+func (f *feed) GenerateFeed(ctx context.Context, format FeedFormat) (*types.Feed, error) {
+	return f.cached(ctx, string(format), format, format.Path(), func(ctx context.Context) (*feeds.Feed, error) {
+		posts, err := f.postService.GetPostsPaged(ctx, 1, feedSize)
+		if err != nil {
+			return nil, err
+		}
+		cfg := config.Get()
+		return f.postsFeed(ctx, cfg.Feed.Title, cfg.Feed.Desc, posts.Content)
+	})
+}
+
+func (f *feed) GenerateTagFeed(ctx context.Context, tag string, format FeedFormat) (*types.Feed, error) {
+	tags := types.NormalizeTags([]string{tag})
+	if len(tags) == 0 {
+		return nil, types.NewErrBadRequest(errors.New("tag is empty"))
+	}
+	tag = tags[0]
+	return f.cached(ctx, "tag:"+tag+":"+string(format), format, tagFeedPath(tag, format), func(ctx context.Context) (*feeds.Feed, error) {
+		posts, err := f.postService.GetPostsByTag(ctx, tag, 1, feedSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(posts.Content) == 0 {
+			return nil, types.ErrNotFound
+		}
+		cfg := config.Get()
+		return f.postsFeed(ctx, cfg.Feed.Title+": "+tag, "Posts tagged "+tag, posts.Content)
+	})
+}
+
+func tagFeedPath(tag string, format FeedFormat) string {
+	return "/tags/" + url.PathEscape(tag) + format.Path()
+}
+
+func (f *feed) Topics(tags []string) []string {
 	cfg := config.Get()
+	topics := []string{}
+	for _, format := range FeedFormats {
+		topics = append(topics, cfg.URL(format.Path()))
+		for _, tag := range types.NormalizeTags(tags) {
+			topics = append(topics, cfg.URL(tagFeedPath(tag, format)))
+		}
+	}
+	return topics
+}
+
+func (f *feed) Topic(ctx context.Context, topic string) (*types.Feed, error) {
+	site, err := url.Parse(config.Get().Website.URL)
+	if err != nil || site.Host == "" {
+		return nil, types.ErrNotFound
+	}
+	u, err := url.Parse(topic)
+	if err != nil || u.Scheme != site.Scheme || u.Host != site.Host || u.RawQuery != "" {
+		return nil, types.ErrNotFound
+	}
+	path, ok := strings.CutPrefix(u.Path, strings.TrimSuffix(site.Path, "/"))
+	if !ok {
+		return nil, types.ErrNotFound
+	}
+	for _, format := range FeedFormats {
+		if path == format.Path() {
+			return f.GenerateFeed(ctx, format)
+		}
+		tag, isTag := strings.CutPrefix(path, "/tags/")
+		tag, isFormat := strings.CutSuffix(tag, format.Path())
+		if isTag && isFormat && tag != "" && !strings.Contains(tag, "/") {
+			return f.GenerateTagFeed(ctx, tag, format)
+		}
+	}
+	return nil, types.ErrNotFound
+}
+
+// GenerateCommentsFeed isn't cached, comments are plain text and cheap to
+// put together
+func (f *feed) GenerateCommentsFeed(ctx context.Context, postId string, format FeedFormat) (*types.Feed, error) {
+	cfg := config.Get()
+	var comments []types.Comment
+	path := "/comments" + format.Path()
+	// titles of the posts the comments are on
+	titles := map[string]string{}
 	feed := &feeds.Feed{
-		Title:       cfg.Feed.Title,
+		Title:       cfg.Feed.Title + ": comments",
 		Link:        &feeds.Link{Href: cfg.Feed.Link},
-		Description: cfg.Feed.Desc,
+		Description: "Latest comments",
 		Author:      &feeds.Author{Name: cfg.Feed.Author, Email: cfg.Feed.Email},
-		Created:     time.Now().UTC(),
 	}
-	items := []*feeds.Item{}
-	posts, err := f.postService.GetPostsPaged(ctx, 1, 30)
+	if postId == "" {
+		latest, err := f.commentService.GetLatestComments(ctx, feedSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range latest {
+			comments = append(comments, c.Comment)
+			titles[c.PostId] = c.PostTitle
+		}
+	} else {
+		post, err := f.postService.GetPostById(ctx, postId)
+		if err != nil {
+			return nil, err
+		}
+		if post.Draft {
+			return nil, types.ErrNotFound
+		}
+		page, err := f.commentService.GetPostCommentsAfter(ctx, postId, "", feedSize)
+		if err != nil {
+			return nil, err
+		}
+		comments = page.Content
+		titles[post.Id] = post.Title
+		feed.Title = cfg.Feed.Title + ": comments on " + post.Title
+		feed.Link = &feeds.Link{Href: cfg.Feed.DetailLink + post.Id}
+		feed.Description = "Comments on " + post.Title
+		path = "/posts/" + url.PathEscape(post.Id) + path
+	}
+
+	for _, c := range comments {
+		title := titles[c.PostId]
+		created, _ := time.Parse(time.RFC3339, c.Created)
+		// commenters' emails are private, only their names are shown
+		feed.Items = append(feed.Items, &feeds.Item{
+			Id:      c.Id,
+			Title:   c.Name + " on " + title,
+			Link:    &feeds.Link{Href: cfg.Feed.DetailLink + c.PostId + "#comments"},
+			Author:  &feeds.Author{Name: c.Name},
+			Created: created,
+			Content: "<p>" + strings.ReplaceAll(html.EscapeString(c.Content), "\n", "<br>") + "</p>",
+		})
+		if created.After(feed.Updated) {
+			feed.Created, feed.Updated = created, created
+		}
+	}
+	// comments aren't published to the hub
+	return encodeFeed(feed, format, feedLinks{self: cfg.URL(path)})
+}
+
+// cached returns the feed stored under key, or builds and stores it. The
+// feed at path is a post feed, it's the one advertising the hub.
+func (f *feed) cached(ctx context.Context, key string, format FeedFormat, path string, build func(context.Context) (*feeds.Feed, error)) (*types.Feed, error) {
+	return cachedVersioned(ctx, f.cache, f.tasks, f.logger, "feed.cache", key, func(ctx context.Context) (*types.Feed, error) {
+		feed, err := build(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cfg := config.Get()
+		return encodeFeed(feed, format, feedLinks{self: cfg.URL(path), hub: websubHub(cfg)})
+	})
+}
+
+// feedLinks are the absolute urls a feed advertises, either may be empty
+type feedLinks struct {
+	self string
+	hub  string
+}
+
+func encodeFeed(feed *feeds.Feed, format FeedFormat, links feedLinks) (*types.Feed, error) {
+	var body string
+	var err error
+	switch format {
+	case FeedRSS:
+		body, err = feed.ToRss()
+		if links != (feedLinks{}) {
+			body, err = feeds.ToXML(&rssLinked{RssFeed: (&feeds.Rss{Feed: feed}).RssFeed(), links: links})
+		}
+	case FeedJSON:
+		jsonFeed := (&feeds.JSON{Feed: feed}).JSONFeed()
+		jsonFeed.FeedUrl = links.self
+		if links.hub != "" {
+			jsonFeed.Hubs = []*feeds.JSONHub{{Type: "WebSub", Url: links.hub}}
+		}
+		body, err = jsonFeed.ToJSON()
+	default:
+		body, err = feed.ToAtom()
+		if links != (feedLinks{}) {
+			body, err = feeds.ToXML(&atomLinked{AtomFeed: (&feeds.Atom{Feed: feed}).AtomFeed(), links: links})
+		}
+	}
 	if err != nil {
-		return "couldn't get posts", err
+		return nil, types.NewErrInternalFailure(err)
+	}
+	sum := sha256.Sum256([]byte(body))
+	return &types.Feed{
+		Body:        body,
+		ContentType: format.ContentType(),
+		ETag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+		Modified:    feed.Updated,
+		Self:        links.self,
+		Hub:         links.hub,
+	}, nil
+}
+
+// atomLinked is an Atom feed with self and hub links next to the alternate
+// one, gorilla/feeds only writes a single link
+type atomLinked struct {
+	*feeds.AtomFeed
+	Links []feeds.AtomLink `xml:"link"`
+	links feedLinks
+}
+
+func (a *atomLinked) FeedXml() interface{} {
+	if a.AtomFeed.Link != nil {
+		a.Links = append(a.Links, *a.AtomFeed.Link)
 	}
-	for _, v := range posts.Content {
+	a.Links = append(a.Links, atomLinks(a.links)...)
+	return a
+}
+
+// rssLinked adds the links to an RSS channel as atom:link elements
+type rssLinked struct {
+	*feeds.RssFeed
+	Links []atomLink
+	links feedLinks
+}
+
+type atomLink struct {
+	XMLName xml.Name `xml:"atom:link"`
+	Href    string   `xml:"href,attr"`
+	Rel     string   `xml:"rel,attr"`
+	Type    string   `xml:"type,attr,omitempty"`
+}
+
+func (r *rssLinked) FeedXml() interface{} {
+	for _, l := range atomLinks(r.links) {
+		r.Links = append(r.Links, atomLink{Href: l.Href, Rel: l.Rel, Type: l.Type})
+	}
+	return &struct {
+		XMLName          xml.Name `xml:"rss"`
+		Version          string   `xml:"version,attr"`
+		ContentNamespace string   `xml:"xmlns:content,attr"`
+		AtomNamespace    string   `xml:"xmlns:atom,attr"`
+		Channel          *rssLinked
+	}{
+		Version:          "2.0",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		AtomNamespace:    "http://www.w3.org/2005/Atom",
+		Channel:          r,
+	}
+}
+
+func atomLinks(links feedLinks) []feeds.AtomLink {
+	var res []feeds.AtomLink
+	if links.self != "" {
+		res = append(res, feeds.AtomLink{Href: links.self, Rel: "self"})
+	}
+	if links.hub != "" {
+		res = append(res, feeds.AtomLink{Href: links.hub, Rel: "hub"})
+	}
+	return res
+}
+
+// postsFeed puts posts in a format neutral feed. It's dated by the newest
+// post, so it only looks updated when there is a new one.
+func (f *feed) postsFeed(ctx context.Context, title, description string, posts []types.Post) (*feeds.Feed, error) {
+	cfg := config.Get()
+	feed := &feeds.Feed{
+		Title:       title,
+		Link:        &feeds.Link{Href: cfg.Feed.Link},
+		Description: description,
+		Author:      &feeds.Author{Name: cfg.Feed.Author, Email: cfg.Feed.Email},
+	}
+	items := []*feeds.Item{}
+	for _, v := range posts {
 		created, _ := time.Parse(time.RFC3339, v.Created)
 		item := &feeds.Item{
 			Id:      v.Id,
@@ -49,13 +361,17 @@ func (f *feed) GenerateFeed(ctx context.Context) (string, error) {
 			Author:  &feeds.Author{Name: cfg.Feed.Author, Email: cfg.Feed.Email},
 			Created: created,
 		}
-		var buf bytes.Buffer
-		if err := goldmark.Convert([]byte(v.Content), &buf); err != nil {
-			return err.Error(), types.NewErrInternalFailure(err)
+		content, err := f.markdown.Render(ctx, v.Content)
+		if err != nil {
+			return nil, err
 		}
-		item.Content = buf.String()
+		item.Content = string(content)
 		items = append(items, item)
+		// pinned posts come first, so the newest isn't always the first
+		if created.After(feed.Updated) {
+			feed.Created, feed.Updated = created, created
+		}
 	}
 	feed.Items = items
-	return feed.ToAtom()
+	return feed, nil
 }
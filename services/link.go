@@ -11,6 +11,7 @@ import (
 	"github.com/yosa12978/echoes/cache"
 	"github.com/yosa12978/echoes/logging"
 	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/tasks"
 	"github.com/yosa12978/echoes/types"
 )
 
@@ -26,10 +27,17 @@ type link struct {
 	linkRepo repos.Link
 	cache    cache.Link
 	logger   logging.Logger
+	tasks    tasks.Runner
 }
 
-func NewLink(linkRepo repos.Link, cache cache.Link, logger logging.Logger) Link {
-	return &link{linkRepo: linkRepo, cache: cache, logger: logger}
+func NewLink(linkRepo repos.Link, cache cache.Link, logger logging.Logger, runner tasks.Runner) Link {
+	return &link{linkRepo: linkRepo, cache: cache, logger: logger, tasks: runner}
+}
+
+func (s *link) background(name string, task tasks.Task) {
+	if err := s.tasks.Submit(name, task); err != nil {
+		s.logger.Error(err.Error())
+	}
 }
 
 func (s *link) GetLinks(ctx context.Context) ([]types.Link, error) {
@@ -48,13 +56,9 @@ func (s *link) GetLinks(ctx context.Context) ([]types.Link, error) {
 		return links, err
 	}
 
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.cache.AddLinks(timeout, links...); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	s.background("links.cache_links", func(ctx context.Context) error {
+		return s.cache.AddLinks(ctx, links...)
+	})
 
 	return links, nil
 }
@@ -86,9 +90,9 @@ func (s *link) CreateLink(ctx context.Context, name, addr, icon string, place in
 		return nil, types.NewErrBadRequest(err)
 	}
 
-	go func() {
-		s.cache.Flush(context.Background())
-	}()
+	s.background("links.cache_flush", func(ctx context.Context) error {
+		return s.cache.Flush(ctx)
+	})
 
 	errCh = make(chan error)
 	go func(errChan chan error) {
@@ -100,13 +104,13 @@ func (s *link) CreateLink(ctx context.Context, name, addr, icon string, place in
 }
 
 func (s *link) DeleteLink(ctx context.Context, id string) (*types.Link, error) {
-	go func() {
-		timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
-		if err := s.cache.Delete(timeout, id); err != nil {
-			s.logger.Error(err.Error())
+	s.background("links.cache_delete", func(ctx context.Context) error {
+		if err := s.cache.Delete(ctx, id); err != nil &&
+			errors.Is(err, types.ErrInternalFailure) {
+			return err
 		}
-	}()
+		return nil
+	})
 	return s.linkRepo.Delete(ctx, id)
 }
 
@@ -160,12 +164,9 @@ func (s *link) GetLinkById(ctx context.Context, id string) (*types.Link, error)
 		return nil, err
 	}
 
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		if err := s.cache.AddLink(timeout, *link); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	cached := *link
+	s.background("links.cache_link", func(ctx context.Context) error {
+		return s.cache.AddLink(ctx, cached)
+	})
 	return link, nil
 }
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+
+	"github.com/yosa12978/echoes/cache"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/markdown"
+	"github.com/yosa12978/echoes/tasks"
+	"github.com/yosa12978/echoes/types"
+)
+
+type Markdown interface {
+	// Render returns the sanitized HTML of src, rendering it only if it
+	// isn't cached yet
+	Render(ctx context.Context, src string) (template.HTML, error)
+	// RenderPost fills in the HTML and the table of contents of post
+	RenderPost(ctx context.Context, post *types.Post) error
+}
+
+type markdownRenderer struct {
+	renderer *markdown.Renderer
+	cache    cache.Markdown
+	logger   logging.Logger
+	tasks    tasks.Runner
+}
+
+func NewMarkdown(renderer *markdown.Renderer, cache cache.Markdown, logger logging.Logger, runner tasks.Runner) Markdown {
+	return &markdownRenderer{
+		renderer: renderer,
+		cache:    cache,
+		logger:   logger,
+		tasks:    runner,
+	}
+}
+
+func (s *markdownRenderer) Render(ctx context.Context, src string) (template.HTML, error) {
+	res, err := s.result(ctx, src)
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(res.HTML), nil
+}
+
+func (s *markdownRenderer) RenderPost(ctx context.Context, post *types.Post) error {
+	res, err := s.result(ctx, post.Content)
+	if err != nil {
+		return err
+	}
+	post.HTML = template.HTML(res.HTML)
+	post.TOCHTML = ""
+	if s.renderer.ShowTOC(res, post.TOC) {
+		post.TOCHTML = template.HTML(res.TOC)
+	}
+	return nil
+}
+
+func (s *markdownRenderer) result(ctx context.Context, src string) (markdown.Result, error) {
+	key := s.renderer.Key(src)
+	var res markdown.Result
+	cached, err := s.cache.Get(ctx, key)
+	if err == nil {
+		if err = json.Unmarshal([]byte(cached), &res); err == nil {
+			return res, nil
+		}
+		err = types.NewErrInternalFailure(err)
+	}
+	if errors.Is(err, types.ErrInternalFailure) {
+		s.logger.Error(err.Error())
+	}
+
+	res, err = s.renderer.Render(src)
+	if err != nil {
+		return res, types.NewErrInternalFailure(err)
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		return res, types.NewErrInternalFailure(err)
+	}
+	if err := s.tasks.Submit("markdown.cache", func(ctx context.Context) error {
+		return s.cache.Set(ctx, key, string(data))
+	}); err != nil {
+		s.logger.Error(err.Error())
+	}
+	return res, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/markdown"
+	"github.com/yosa12978/echoes/media"
+	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/types"
+)
+
+type Media interface {
+	GetMedia(ctx context.Context, page, size int) (*types.Page[types.Media], error)
+	GetMediaById(ctx context.Context, id string) (*types.Media, error)
+	// Upload stores size bytes of r. The type is sniffed from the content,
+	// name is only kept to label the file.
+	Upload(ctx context.Context, name string, r io.Reader, size int64) (*types.Media, error)
+	// Open reads the file stored under key
+	Open(ctx context.Context, key string) (*media.Object, error)
+	DeleteMedia(ctx context.Context, id string) (*types.Media, error)
+	MaxSize() int64
+	markdown.ImageResolver
+}
+
+// mediaTypes are the types that can be uploaded and the extensions they
+// are stored with. SVG is left out, it can carry scripts.
+var mediaTypes = map[string]string{
+	"image/png":       ".png",
+	"image/jpeg":      ".jpg",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/avif":      ".avif",
+	"application/pdf": ".pdf",
+	"video/mp4":       ".mp4",
+	"video/webm":      ".webm",
+	"audio/mpeg":      ".mp3",
+	"audio/wave":      ".wav",
+	"application/ogg": ".ogg",
+}
+
+// processedTypes are the image types that get variants and have their
+// metadata stripped
+var processedTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type mediaService struct {
+	repo        repos.Media
+	store       media.Store
+	logger      logging.Logger
+	maxSize     int64
+	imageWidths []int
+}
+
+// NewMedia stores uploads of at most maxSize bytes in store. Images wider
+// than any of imageWidths get a copy resized to it.
+func NewMedia(repo repos.Media, store media.Store, logger logging.Logger, maxSize int64, imageWidths []int) Media {
+	return &mediaService{
+		repo:        repo,
+		store:       store,
+		logger:      logger,
+		maxSize:     maxSize,
+		imageWidths: imageWidths,
+	}
+}
+
+func (s *mediaService) withURL(m *types.Media) *types.Media {
+	m.URL = s.store.URL(m.Key)
+	for i := range m.Variants {
+		m.Variants[i].URL = s.store.URL(m.Variants[i].Key)
+	}
+	return m
+}
+
+func (s *mediaService) MaxSize() int64 {
+	return s.maxSize
+}
+
+func (s *mediaService) GetMedia(ctx context.Context, page, size int) (*types.Page[types.Media], error) {
+	res, err := s.repo.GetPage(ctx, page, size)
+	if err != nil {
+		return res, err
+	}
+	for i := range res.Content {
+		s.withURL(&res.Content[i])
+	}
+	return res, nil
+}
+
+func (s *mediaService) GetMediaById(ctx context.Context, id string) (*types.Media, error) {
+	m, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withURL(m), nil
+}
+
+func (s *mediaService) Upload(ctx context.Context, name string, r io.Reader, size int64) (*types.Media, error) {
+	if size <= 0 {
+		return nil, types.NewErrBadRequest(errors.New("file is empty"))
+	}
+	if size > s.maxSize {
+		m := types.Media{Size: s.maxSize}
+		return nil, types.NewErrBadRequest(fmt.Errorf("file is larger than %s", m.HumanSize()))
+	}
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	ext, ok := mediaTypes[contentType]
+	if !ok {
+		return nil, types.NewErrBadRequest(fmt.Errorf("%s files can't be uploaded", contentType))
+	}
+
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		name = "file" + ext
+	}
+	now := time.Now().UTC()
+	id := uuid.NewString()
+	m := types.Media{
+		Id:          id,
+		Name:        name,
+		Key:         now.Format("2006/01/") + id + ext,
+		ContentType: contentType,
+		Size:        size,
+		Created:     now.Format(time.RFC3339),
+	}
+	if processedTypes[contentType] {
+		return s.uploadImage(ctx, m, br)
+	}
+	if err := s.store.Put(ctx, m.Key, br, size, contentType); err != nil {
+		return nil, err
+	}
+	return s.create(ctx, m, m.Key)
+}
+
+// uploadImage stores the image without its metadata, along with its
+// variants
+func (s *mediaService) uploadImage(ctx context.Context, m types.Media, r io.Reader) (*types.Media, error) {
+	data, err := io.ReadAll(io.LimitReader(r, m.Size))
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	img, err := media.ProcessImage(data, m.ContentType, s.imageWidths)
+	if err != nil {
+		return nil, types.NewErrBadRequest(err)
+	}
+	m.Size = int64(len(img.Data))
+	m.Width, m.Height = img.Width, img.Height
+	stored := []string{}
+	put := func(key string, data []byte, contentType string) error {
+		if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			return err
+		}
+		stored = append(stored, key)
+		return nil
+	}
+	if err := put(m.Key, img.Data, m.ContentType); err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(m.Key, path.Ext(m.Key))
+	for _, v := range img.Variants {
+		variant := types.MediaVariant{
+			Key:         base + v.Suffix + v.Ext,
+			ContentType: v.ContentType,
+			Width:       v.Width,
+			Height:      v.Height,
+			Size:        int64(len(v.Data)),
+		}
+		if err := put(variant.Key, v.Data, v.ContentType); err != nil {
+			s.deleteFiles(ctx, stored...)
+			return nil, err
+		}
+		m.Variants = append(m.Variants, variant)
+	}
+	return s.create(ctx, m, stored...)
+}
+
+// create saves m, removing its stored files if that fails
+func (s *mediaService) create(ctx context.Context, m types.Media, stored ...string) (*types.Media, error) {
+	created, err := s.repo.Create(ctx, m)
+	if err != nil {
+		s.deleteFiles(ctx, stored...)
+		return nil, err
+	}
+	return s.withURL(created), nil
+}
+
+// deleteFiles removes keys from the store, logging the ones that fail
+func (s *mediaService) deleteFiles(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.logger.Error(err.Error())
+		}
+	}
+}
+
+func (s *mediaService) Open(ctx context.Context, key string) (*media.Object, error) {
+	return s.store.Open(ctx, key)
+}
+
+func (s *mediaService) DeleteMedia(ctx context.Context, id string) (*types.Media, error) {
+	m, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Delete(ctx, m.Key); err != nil {
+		return nil, err
+	}
+	for _, v := range m.Variants {
+		s.deleteFiles(ctx, v.Key)
+	}
+	return s.withURL(m), nil
+}
+
+// ResolveImage finds the upload served at src for the Markdown renderer
+func (s *mediaService) ResolveImage(src string) (markdown.Image, bool) {
+	prefix := s.store.URL("")
+	key, ok := strings.CutPrefix(src, prefix)
+	if !ok || key == "" {
+		return markdown.Image{}, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	m, err := s.repo.FindByKey(ctx, key)
+	if err != nil {
+		if !errors.Is(err, types.ErrNotFound) {
+			s.logger.Error(err.Error())
+		}
+		return markdown.Image{}, false
+	}
+	s.withURL(m)
+	img := markdown.Image{Type: m.ContentType, Width: m.Width, Height: m.Height}
+	for _, v := range m.Variants {
+		img.Sources = append(img.Sources, markdown.ImageSource{URL: v.URL, Type: v.ContentType, Width: v.Width})
+	}
+	return img, true
+}
@@ -8,37 +8,136 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/yosa12978/echoes/cache"
+	"github.com/yosa12978/echoes/jobs"
 	"github.com/yosa12978/echoes/logging"
 	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/tasks"
 	"github.com/yosa12978/echoes/types"
 )
 
 type Post interface {
 	GetPosts(ctx context.Context) ([]types.Post, error)
 	GetPostsPaged(ctx context.Context, page, size int) (*types.Page[types.Post], error)
+	// GetPostsAfter pages through posts with an opaque cursor taken from
+	// types.Page.NextCursor. An empty cursor returns the first page.
+	GetPostsAfter(ctx context.Context, cursor string, size int) (*types.Page[types.Post], error)
+	// GetPostsByTag returns published posts tagged with tag, newest first
+	GetPostsByTag(ctx context.Context, tag string, page, size int) (*types.Page[types.Post], error)
+	// GetPostById finds drafts too, readers get GetPublishedPostById
 	GetPostById(ctx context.Context, id string) (*types.Post, error)
+	// GetPublishedPostById is GetPostById without drafts, they aren't found
+	GetPublishedPostById(ctx context.Context, id string) (*types.Post, error)
+	// PublishPost turns a draft into a published post
+	PublishPost(ctx context.Context, id string) (*types.Post, error)
 	// pin post works like a trigger
 	PinPost(ctx context.Context, id string) (*types.Post, error)
-	CreatePost(ctx context.Context, title, content string, tweet bool) (*types.Post, error)
+	CreatePost(ctx context.Context, title, content string, tweet, toc bool) (*types.Post, error)
 	DeletePost(ctx context.Context, id string) (*types.Post, error)
+	// ImportPost stores an existing post keeping its id and creation date.
+	// Posts without an id get a new one.
+	ImportPost(ctx context.Context, post types.Post) (*types.Post, error)
+	// Reindex sends every post to the search index again
+	Reindex(ctx context.Context) (int, error)
 	Seed(ctx context.Context) error
 	Search(ctx context.Context, query string, page, size int) (*types.Page[types.Post], error)
 }
 
+const (
+	JobSearchIndex  = "search.index"
+	JobSearchDelete = "search.delete"
+)
+
 type post struct {
 	postRepo     repos.Post
 	postCache    cache.Post
 	logger       logging.Logger
 	postSearcher repos.PostSearcher
+	tasks        tasks.Runner
+	queue        jobs.Queue
+	markdown     Markdown
 }
 
-func NewPost(postRepo repos.Post, postCache cache.Post, logger logging.Logger, postSearcher repos.PostSearcher) Post {
-	return &post{
+func NewPost(postRepo repos.Post, postCache cache.Post, logger logging.Logger, postSearcher repos.PostSearcher, runner tasks.Runner, queue jobs.Queue, markdown Markdown) Post {
+	s := &post{
 		postRepo:     postRepo,
 		postCache:    postCache,
 		logger:       logger,
 		postSearcher: postSearcher,
+		tasks:        runner,
+		queue:        queue,
+		markdown:     markdown,
+	}
+	queue.Handle(JobSearchIndex, s.indexPost)
+	queue.Handle(JobSearchDelete, s.unindexPost)
+	return s
+}
+
+func (s *post) enqueue(ctx context.Context, kind string, payload any) {
+	if _, err := s.queue.Enqueue(ctx, kind, payload); err != nil {
+		s.logger.Error("failed to enqueue job", "kind", kind, "error", err.Error())
+	}
+}
+
+// changed runs the cache update of a changed post in the background and
+// pings the hub once it's done, so subscribers don't fetch a stale feed.
+// Drafts aren't in the feeds, they only update the cache.
+func (s *post) changed(name string, post *types.Post, update tasks.Task) {
+	s.background(name, func(ctx context.Context) error {
+		if err := update(ctx); err != nil {
+			return err
+		}
+		if !post.Draft {
+			s.enqueue(ctx, JobWebSubPublish, post.Tags)
+		}
+		return nil
+	})
+}
+
+func (s *post) indexPost(ctx context.Context, job types.Job) error {
+	id, err := jobs.Decode[string](job)
+	if err != nil {
+		return err
+	}
+	// drafts are indexed once they're published
+	post, err := s.postRepo.FindPublishedById(ctx, id)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.postSearcher.Append(ctx, *post)
+}
+
+func (s *post) unindexPost(ctx context.Context, job types.Job) error {
+	id, err := jobs.Decode[string](job)
+	if err != nil {
+		return err
+	}
+	return s.postSearcher.Delete(ctx, id)
+}
+
+func (s *post) background(name string, task tasks.Task) {
+	if err := s.tasks.Submit(name, task); err != nil {
+		s.logger.Error(err.Error())
+	}
+}
+
+// renderPost fills in the HTML of a post that was stored or cached without it
+func (s *post) renderPost(ctx context.Context, post *types.Post) error {
+	if post.HTML != "" {
+		return nil
 	}
+	return s.markdown.RenderPost(ctx, post)
+}
+
+func (s *post) render(ctx context.Context, posts []types.Post) error {
+	for i := range posts {
+		if err := s.renderPost(ctx, &posts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *post) GetPosts(ctx context.Context) ([]types.Post, error) {
@@ -53,32 +152,56 @@ func (s *post) GetPostsPaged(ctx context.Context, page, size int) (*types.Page[t
 		}
 	}
 	if pageFromCache != nil {
-		return pageFromCache, nil
+		return pageFromCache, s.render(ctx, pageFromCache.Content)
 	}
 
-	t := time.UnixMicro(version).Format(time.RFC3339)
+	t := time.UnixMicro(version).UTC().Format(time.RFC3339)
 	postsPage, err := s.postRepo.GetPageTime(ctx, t, page, size)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.render(ctx, postsPage.Content); err != nil {
+		return nil, err
+	}
 
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.postCache.AddPageOfPosts(timeout, page, *postsPage); err != nil {
-			if errors.Is(err, types.ErrInternalFailure) {
-				s.logger.Error(err.Error())
-			}
-		}
-	}()
+	s.background("posts.cache_page", func(ctx context.Context) error {
+		return s.postCache.AddPageOfPosts(ctx, page, *postsPage)
+	})
 
 	return postsPage, err
 }
 
+func (s *post) GetPostsAfter(ctx context.Context, cursor string, size int) (*types.Page[types.Post], error) {
+	if size < 1 {
+		return nil, types.NewErrBadRequest(errors.New("size must be positive"))
+	}
+	after, err := types.DecodeCursor[types.PostCursor](cursor)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := s.postRepo.GetPageAfter(ctx, after, size)
+	if err != nil {
+		return nil, err
+	}
+	return posts, s.render(ctx, posts.Content)
+}
+
+func (s *post) GetPostsByTag(ctx context.Context, tag string, page, size int) (*types.Page[types.Post], error) {
+	tags := types.NormalizeTags([]string{tag})
+	if len(tags) == 0 {
+		return nil, types.NewErrBadRequest(errors.New("tag is empty"))
+	}
+	posts, err := s.postRepo.GetPageByTag(ctx, tags[0], page, size)
+	if err != nil {
+		return nil, err
+	}
+	return posts, s.render(ctx, posts.Content)
+}
+
 func (s *post) GetPostById(ctx context.Context, id string) (*types.Post, error) {
 	postFromCache, err := s.postCache.GetPostById(ctx, id)
 	if err == nil {
-		return postFromCache, nil
+		return postFromCache, s.renderPost(ctx, postFromCache)
 	}
 	if errors.Is(err, types.ErrInternalFailure) {
 		s.logger.Error(err.Error())
@@ -88,16 +211,49 @@ func (s *post) GetPostById(ctx context.Context, id string) (*types.Post, error)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.renderPost(ctx, post); err != nil {
+		return nil, err
+	}
 
-	go func() {
-		if err := s.postCache.AddPost(ctx, *post); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
+	cached := *post
+	s.background("posts.cache_post", func(ctx context.Context) error {
+		return s.postCache.AddPost(ctx, cached)
+	})
 
 	return post, nil
 }
 
+func (s *post) GetPublishedPostById(ctx context.Context, id string) (*types.Post, error) {
+	post, err := s.GetPostById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if post.Draft {
+		return nil, types.NewErrNotFound(fmt.Errorf("post %s is a draft", id))
+	}
+	return post, nil
+}
+
+func (s *post) PublishPost(ctx context.Context, id string) (*types.Post, error) {
+	post, err := s.postRepo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !post.Draft {
+		return nil, types.NewErrBadRequest(fmt.Errorf("post %s is already published", id))
+	}
+	published, err := s.postRepo.Publish(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.enqueue(ctx, JobSearchIndex, id)
+	s.changed("posts.cache_delete", published, func(ctx context.Context) error {
+		return s.postCache.Delete(ctx, id)
+	})
+	return published, nil
+}
+
 func (s *post) PinPost(ctx context.Context, id string) (*types.Post, error) {
 	post, err := s.GetPostById(ctx, id)
 	if err != nil {
@@ -105,18 +261,21 @@ func (s *post) PinPost(ctx context.Context, id string) (*types.Post, error) {
 	}
 	post.Pinned = !post.Pinned
 
-	go func() {
-		if err := s.postCache.PinPost(ctx, id); err != nil {
-			if errors.Is(err, types.ErrInternalFailure) {
-				s.logger.Error(err.Error())
-			}
+	updated, err := s.postRepo.Update(ctx, post.Id, *post)
+	if err != nil {
+		return nil, err
+	}
+	s.changed("posts.cache_pin", updated, func(ctx context.Context) error {
+		if err := s.postCache.PinPost(ctx, id); err != nil &&
+			errors.Is(err, types.ErrInternalFailure) {
+			return err
 		}
-	}()
-
-	return s.postRepo.Update(ctx, post.Id, *post)
+		return nil
+	})
+	return updated, nil
 }
 
-func (s *post) CreatePost(ctx context.Context, title, content string, tweet bool) (*types.Post, error) {
+func (s *post) CreatePost(ctx context.Context, title, content string, tweet, toc bool) (*types.Post, error) {
 	id := uuid.NewString()
 	post := types.Post{
 		Id:      id,
@@ -125,28 +284,69 @@ func (s *post) CreatePost(ctx context.Context, title, content string, tweet bool
 		Created: time.Now().UTC().Format(time.RFC3339),
 		Pinned:  false,
 		Tweet:   tweet,
+		TOC:     toc,
 	}
 
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.postCache.AddPost(timeout, post); err != nil {
-			s.logger.Error(err.Error())
-		}
-	}()
-
-	return s.postRepo.Create(ctx, post)
+	created, err := s.postRepo.Create(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+	s.enqueue(ctx, JobSearchIndex, created.Id)
+	s.changed("posts.cache_post", created, func(ctx context.Context) error {
+		return s.postCache.AddPost(ctx, post)
+	})
+	return created, nil
 }
 
 func (s *post) DeletePost(ctx context.Context, id string) (*types.Post, error) {
-	go func() {
-		timeout, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.postCache.Delete(timeout, id); err != nil {
-			s.logger.Error(err.Error())
+	deleted, err := s.postRepo.Delete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.enqueue(ctx, JobSearchDelete, id)
+	s.changed("posts.cache_delete", deleted, func(ctx context.Context) error {
+		return s.postCache.Delete(ctx, id)
+	})
+	return deleted, nil
+}
+
+func (s *post) ImportPost(ctx context.Context, post types.Post) (*types.Post, error) {
+	if post.Id == "" {
+		post.Id = uuid.NewString()
+	} else if _, err := s.postRepo.FindById(ctx, post.Id); err == nil {
+		return nil, types.NewErrBadRequest(fmt.Errorf("post %s already exists", post.Id))
+	}
+	if post.Created == "" {
+		post.Created = time.Now().UTC().Format(time.RFC3339)
+	}
+	post.Comments = 0
+
+	created, err := s.postRepo.Create(ctx, post)
+	if err != nil {
+		return nil, err
+	}
+	s.enqueue(ctx, JobSearchIndex, created.Id)
+	s.changed("posts.cache_post", created, func(ctx context.Context) error {
+		return s.postCache.AddPost(ctx, post)
+	})
+	return created, nil
+}
+
+func (s *post) Reindex(ctx context.Context) (int, error) {
+	all, err := s.postRepo.FindAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	posts := make([]types.Post, 0, len(all))
+	for _, post := range all {
+		if !post.Draft {
+			posts = append(posts, post)
 		}
-	}()
-	return s.postRepo.Delete(ctx, id)
+	}
+	if err := s.postSearcher.Bulk(ctx, posts...); err != nil {
+		return 0, types.NewErrInternalFailure(err)
+	}
+	return len(posts), nil
 }
 
 func (s *post) Seed(ctx context.Context) error {
@@ -161,5 +361,9 @@ func (s *post) Seed(ctx context.Context) error {
 }
 
 func (s *post) Search(ctx context.Context, query string, page, size int) (*types.Page[types.Post], error) {
-	return s.postSearcher.Search(ctx, query, page, size)
+	posts, err := s.postSearcher.Search(ctx, query, page, size)
+	if err != nil {
+		return nil, err
+	}
+	return posts, s.render(ctx, posts.Content)
 }
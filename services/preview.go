@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image/color"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/yosa12978/echoes/cache"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/media"
+	"github.com/yosa12978/echoes/tasks"
+	"github.com/yosa12978/echoes/types"
+	"golang.org/x/image/font/opentype"
+)
+
+// Preview draws the social preview images of posts that have no image of
+// their own
+type Preview interface {
+	// PostPreview returns the PNG preview of a post and a version that
+	// changes whenever the image does. Drafts have no preview, they aren't
+	// found.
+	PostPreview(ctx context.Context, id string) ([]byte, string, error)
+}
+
+const (
+	previewFont   = "assets/fonts/Rubik-Regular.ttf"
+	previewColors = "assets/css/colorscheme.css"
+)
+
+// previewDefaults are the Catppuccin Latte colors of the bundled
+// colorscheme, used when it can't be read
+var previewDefaults = map[string]color.Color{
+	"card-bg": color.NRGBA{0xef, 0xf1, 0xf5, 0xff},
+	"card-fg": color.NRGBA{0x4c, 0x4f, 0x69, 0xff},
+	"primary": color.NRGBA{0x11, 0x11, 0x1b, 0xff},
+	"warning": color.NRGBA{0xfe, 0x64, 0x0b, 0xff},
+}
+
+// previewTheme is everything drawn on previews that doesn't come from the
+// post. It's read from the assets once.
+type previewTheme struct {
+	template media.Preview
+	// fingerprint changes with the files the theme is read from
+	fingerprint string
+}
+
+type preview struct {
+	posts  Post
+	cache  cache.Preview
+	logger logging.Logger
+	tasks  tasks.Runner
+
+	once  sync.Once
+	theme previewTheme
+	err   error
+}
+
+func NewPreview(posts Post, cache cache.Preview, logger logging.Logger, runner tasks.Runner) Preview {
+	return &preview{
+		posts:  posts,
+		cache:  cache,
+		logger: logger,
+		tasks:  runner,
+	}
+}
+
+func (s *preview) PostPreview(ctx context.Context, id string) ([]byte, string, error) {
+	post, err := s.posts.GetPublishedPostById(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	s.once.Do(func() { s.theme, s.err = loadPreviewTheme(s.logger) })
+	if s.err != nil {
+		return nil, "", types.NewErrInternalFailure(s.err)
+	}
+
+	cfg := config.Get()
+	p := s.theme.template
+	p.Title = post.Title
+	p.SiteName = cfg.Website.Title
+	if u, err := url.Parse(cfg.Website.URL); err == nil {
+		p.Footer = u.Host
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{s.theme.fingerprint, p.Title, p.SiteName, p.Footer}, "\x00")))
+	version := hex.EncodeToString(sum[:8])
+
+	image, err := s.cache.Get(ctx, post.Id, version)
+	if err == nil {
+		return image, version, nil
+	}
+	if errors.Is(err, types.ErrInternalFailure) {
+		s.logger.Error(err.Error())
+	}
+
+	image, err = media.RenderPreview(p)
+	if err != nil {
+		return nil, "", types.NewErrInternalFailure(err)
+	}
+	if err := s.tasks.Submit("preview.cache", func(ctx context.Context) error {
+		return s.cache.Set(ctx, post.Id, version, image)
+	}); err != nil {
+		s.logger.Error(err.Error())
+	}
+	return image, version, nil
+}
+
+// loadPreviewTheme reads the font, the colorscheme and the site logo. Only
+// a missing font is an error, the rest falls back to defaults.
+func loadPreviewTheme(logger logging.Logger) (previewTheme, error) {
+	fingerprint := sha256.New()
+	read := func(path string) ([]byte, error) {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			fingerprint.Write(data)
+		}
+		return data, err
+	}
+
+	data, err := read(previewFont)
+	if err != nil {
+		return previewTheme{}, err
+	}
+	font, err := opentype.Parse(data)
+	if err != nil {
+		return previewTheme{}, err
+	}
+
+	colors := map[string]color.Color{}
+	if css, err := read(previewColors); err == nil {
+		colors = media.PreviewColors(css)
+	} else {
+		logger.Warn("preview images use the default colors", "error", err)
+	}
+	pick := func(name string) color.Color {
+		if c, ok := colors[name]; ok {
+			return c
+		}
+		return previewDefaults[name]
+	}
+	theme := previewTheme{template: media.Preview{
+		Font:       font,
+		Background: pick("card-bg"),
+		Text:       pick("card-fg"),
+		Heading:    pick("primary"),
+		Accent:     pick("warning"),
+	}}
+
+	// only logos among the assets can be drawn, others aren't fetched
+	if logo := config.Get().Website.Logo; strings.HasPrefix(logo, "/assets/") {
+		data, err := read(filepath.FromSlash(strings.TrimPrefix(logo, "/")))
+		if err == nil {
+			theme.template.Logo, err = media.DecodeLogo(data)
+		}
+		if err != nil {
+			logger.Warn("preview images are drawn without the logo", "logo", logo, "error", err)
+		}
+	}
+	theme.fingerprint = hex.EncodeToString(fingerprint.Sum(nil))
+	return theme, nil
+}
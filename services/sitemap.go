@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/yosa12978/echoes/cache"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/tasks"
+	"github.com/yosa12978/echoes/types"
+)
+
+type Sitemap interface {
+	// GenerateSitemap returns the sitemap of the site at base, or an index
+	// of numbered sitemaps once the site has more urls than one can list
+	GenerateSitemap(ctx context.Context, base string) (*types.Sitemap, error)
+	// GenerateSitemapPage returns the numbered sitemap page, counting from 1
+	GenerateSitemapPage(ctx context.Context, base string, page int) (*types.Sitemap, error)
+}
+
+// sitemapURLs is the most urls a sitemap may list
+const sitemapURLs = 50000
+
+// sitemapPaths are the pages listed ahead of the posts in the first sitemap
+var sitemapPaths = []string{"/", "/blog"}
+
+// sitemapPosts is the number of posts in each sitemap
+var sitemapPosts = sitemapURLs - len(sitemapPaths)
+
+// sitemapPath is where the numbered sitemap page is served
+func sitemapPath(page int) string {
+	return "/sitemaps/" + strconv.Itoa(page) + ".xml"
+}
+
+const sitemapXmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	Xmlns    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+type sitemap struct {
+	postRepo repos.Post
+	cache    cache.Versioned[types.Sitemap]
+	logger   logging.Logger
+	tasks    tasks.Runner
+}
+
+func NewSitemap(postRepo repos.Post, cache cache.Versioned[types.Sitemap], logger logging.Logger, runner tasks.Runner) Sitemap {
+	return &sitemap{
+		postRepo: postRepo,
+		cache:    cache,
+		logger:   logger,
+		tasks:    runner,
+	}
+}
+
+func (s *sitemap) GenerateSitemap(ctx context.Context, base string) (*types.Sitemap, error) {
+	return s.cached(ctx, base, "index", func(ctx context.Context) (*types.Sitemap, error) {
+		posts, err := s.postRepo.GetIndex(ctx, 1, sitemapPosts)
+		if err != nil {
+			return nil, err
+		}
+		if !posts.HasNext {
+			return s.urlSet(base, 1, posts.Content)
+		}
+
+		index := sitemapIndex{Xmlns: sitemapXmlns}
+		var modified time.Time
+		for page := 1; ; page++ {
+			if page > 1 {
+				if posts, err = s.postRepo.GetIndex(ctx, page, sitemapPosts); err != nil {
+					return nil, err
+				}
+			}
+			// posts are oldest first, the last one is the newest on the page
+			lastMod := ""
+			if n := len(posts.Content); n > 0 {
+				lastMod = posts.Content[n-1].Created
+				if t, err := time.Parse(time.RFC3339, lastMod); err == nil && t.After(modified) {
+					modified = t
+				}
+			}
+			index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: base + sitemapPath(page), LastMod: lastMod})
+			if !posts.HasNext {
+				break
+			}
+		}
+		return encodeSitemap(index, modified)
+	})
+}
+
+func (s *sitemap) GenerateSitemapPage(ctx context.Context, base string, page int) (*types.Sitemap, error) {
+	if page < 1 {
+		return nil, types.ErrNotFound
+	}
+	return s.cached(ctx, base, strconv.Itoa(page), func(ctx context.Context) (*types.Sitemap, error) {
+		posts, err := s.postRepo.GetIndex(ctx, page, sitemapPosts)
+		if err != nil {
+			return nil, err
+		}
+		if page > 1 && len(posts.Content) == 0 {
+			return nil, types.ErrNotFound
+		}
+		return s.urlSet(base, page, posts.Content)
+	})
+}
+
+// urlSet lists the posts of a sitemap page, the first page starts with the
+// other pages of the site
+func (s *sitemap) urlSet(base string, page int, posts []types.Post) (*types.Sitemap, error) {
+	set := sitemapURLSet{Xmlns: sitemapXmlns}
+	if page == 1 {
+		for _, path := range sitemapPaths {
+			set.URLs = append(set.URLs, sitemapURL{Loc: base + path})
+		}
+	}
+	var modified time.Time
+	for _, post := range posts {
+		set.URLs = append(set.URLs, sitemapURL{Loc: base + "/posts/" + url.PathEscape(post.Id), LastMod: post.Created})
+		if t, err := time.Parse(time.RFC3339, post.Created); err == nil && t.After(modified) {
+			modified = t
+		}
+	}
+	return encodeSitemap(set, modified)
+}
+
+func encodeSitemap(doc any, modified time.Time) (*types.Sitemap, error) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, types.NewErrInternalFailure(err)
+	}
+	return &types.Sitemap{
+		Body:     xml.Header + string(body),
+		Modified: modified,
+	}, nil
+}
+
+// cached returns the sitemap stored under key. Sitemaps are only cached for
+// the configured website url, the base of any other comes from the request
+// Host and every client could fill the cache with its own.
+func (s *sitemap) cached(ctx context.Context, base, key string, build func(context.Context) (*types.Sitemap, error)) (*types.Sitemap, error) {
+	if base != config.Get().URL("") {
+		return build(ctx)
+	}
+	return cachedVersioned(ctx, s.cache, s.tasks, s.logger, "sitemap.cache", key, build)
+}
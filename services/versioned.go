@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yosa12978/echoes/cache"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/tasks"
+	"github.com/yosa12978/echoes/types"
+)
+
+// cachedVersioned returns the document stored under key, or builds it and
+// stores it in the background under the version it was missing at
+func cachedVersioned[T any](
+	ctx context.Context,
+	c cache.Versioned[T],
+	runner tasks.Runner,
+	logger logging.Logger,
+	task, key string,
+	build func(context.Context) (*T, error),
+) (*T, error) {
+	cached, version, err := c.Get(ctx, key)
+	if err == nil {
+		return cached, nil
+	}
+	if errors.Is(err, types.ErrInternalFailure) {
+		logger.Error(err.Error())
+	}
+
+	res, err := build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 {
+		if err := runner.Submit(task, func(ctx context.Context) error {
+			return c.Set(ctx, version, key, *res)
+		}); err != nil {
+			logger.Error(err.Error())
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/jobs"
+	"github.com/yosa12978/echoes/logging"
+	"github.com/yosa12978/echoes/repos"
+	"github.com/yosa12978/echoes/types"
+)
+
+// WebSub pushes post feeds to subscribers, through an external hub or the
+// built-in one, whenever a post is published, changed or deleted
+type WebSub interface {
+	// Subscribe takes a subscription request to the built-in hub. The
+	// subscriber is asked to confirm it in the background.
+	Subscribe(ctx context.Context, req types.SubscriptionRequest) error
+}
+
+const (
+	// JobWebSubPublish carries the tags of a changed post, it's enqueued
+	// by the post service once the post cache is updated
+	JobWebSubPublish = "websub.publish"
+	JobWebSubVerify  = "websub.verify"
+	JobWebSubDeliver = "websub.deliver"
+)
+
+// WebSubHubPath is where the built-in hub takes subscriptions
+const WebSubHubPath = "/websub"
+
+// websubHub is the hub feeds advertise, empty when WebSub is off
+func websubHub(cfg config.Config) string {
+	switch cfg.WebSub.Hub {
+	case "":
+		return ""
+	case "builtin":
+		return cfg.URL(WebSubHubPath)
+	}
+	return cfg.WebSub.Hub
+}
+
+type websub struct {
+	repo     repos.Subscription
+	feed     Feed
+	queue    jobs.Queue
+	logger   logging.Logger
+	client   *http.Client
+	maxLease time.Duration
+}
+
+// NewWebSub handles the publish jobs of the post service, with or without
+// WebSub turned on, so they don't end up dead lettered
+func NewWebSub(repo repos.Subscription, feed Feed, queue jobs.Queue, logger logging.Logger, maxLease time.Duration) WebSub {
+	s := &websub{
+		repo:     repo,
+		feed:     feed,
+		queue:    queue,
+		logger:   logger,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		maxLease: maxLease,
+	}
+	queue.Handle(JobWebSubPublish, s.publish)
+	queue.Handle(JobWebSubVerify, s.verify)
+	queue.Handle(JobWebSubDeliver, s.deliver)
+	return s
+}
+
+func (s *websub) builtin() bool {
+	return config.Get().WebSub.Hub == "builtin"
+}
+
+func (s *websub) Subscribe(ctx context.Context, req types.SubscriptionRequest) error {
+	if !s.builtin() {
+		return types.ErrNotFound
+	}
+	if req.Mode != "subscribe" && req.Mode != "unsubscribe" {
+		return types.NewErrBadRequest(fmt.Errorf("hub.mode %q isn't supported", req.Mode))
+	}
+	callback, err := url.Parse(req.Callback)
+	if err != nil || callback.Host == "" || (callback.Scheme != "http" && callback.Scheme != "https") {
+		return types.NewErrBadRequest(errors.New("hub.callback must be an http(s) url"))
+	}
+	if len(req.Secret) >= 200 {
+		return types.NewErrBadRequest(errors.New("hub.secret must be shorter than 200 bytes"))
+	}
+	if req.Mode == "subscribe" {
+		if _, err := s.feed.Topic(ctx, req.Topic); err != nil {
+			if errors.Is(err, types.ErrNotFound) {
+				return types.NewErrBadRequest(fmt.Errorf("hub.topic %q isn't a feed published here", req.Topic))
+			}
+			return err
+		}
+	}
+	lease := time.Duration(req.Lease) * time.Second
+	if lease <= 0 || lease > s.maxLease {
+		lease = s.maxLease
+	}
+	req.Lease = int(lease.Seconds())
+	_, err = s.queue.Enqueue(ctx, JobWebSubVerify, req)
+	return err
+}
+
+// verify asks the subscriber to echo a challenge. Unanswered requests are
+// retried, refused ones are dropped.
+func (s *websub) verify(ctx context.Context, job types.Job) error {
+	req, err := jobs.Decode[types.SubscriptionRequest](job)
+	if err != nil {
+		return err
+	}
+	challenge := make([]byte, 16)
+	rand.Read(challenge)
+	u, err := url.Parse(req.Callback)
+	if err != nil {
+		return nil
+	}
+	query := u.Query()
+	query.Set("hub.mode", req.Mode)
+	query.Set("hub.topic", req.Topic)
+	query.Set("hub.challenge", hex.EncodeToString(challenge))
+	if req.Mode == "subscribe" {
+		query.Set("hub.lease_seconds", fmt.Sprint(req.Lease))
+	}
+	u.RawQuery = query.Encode()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 || strings.TrimSpace(string(body)) != hex.EncodeToString(challenge) {
+		s.logger.Warn("websub subscriber didn't confirm", "callback", req.Callback, "topic", req.Topic, "mode", req.Mode, "status", resp.StatusCode)
+		return nil
+	}
+
+	if req.Mode == "unsubscribe" {
+		return s.repo.Delete(ctx, req.Callback, req.Topic)
+	}
+	now := time.Now().UTC()
+	return s.repo.Save(ctx, types.Subscription{
+		Id:       uuid.NewString(),
+		Callback: req.Callback,
+		Topic:    req.Topic,
+		Secret:   req.Secret,
+		Expires:  now.Add(time.Duration(req.Lease) * time.Second).Format(time.RFC3339),
+		Created:  now.Format(time.RFC3339),
+	})
+}
+
+// publish tells the external hub the feeds of a post changed, or has the
+// built-in one deliver them to each subscriber
+func (s *websub) publish(ctx context.Context, job types.Job) error {
+	cfg := config.Get()
+	hub := websubHub(cfg)
+	if hub == "" {
+		return nil
+	}
+	tags, err := jobs.Decode[[]string](job)
+	if err != nil {
+		return err
+	}
+	topics := s.feed.Topics(tags)
+	if !s.builtin() {
+		return s.notifyHub(ctx, hub, topics)
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := s.repo.DeleteExpired(ctx, now); err != nil {
+		return err
+	}
+	for _, topic := range topics {
+		subs, err := s.repo.FindActive(ctx, topic, now)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			if _, err := s.queue.Enqueue(ctx, JobWebSubDeliver, sub.Id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *websub) notifyHub(ctx context.Context, hub string, topics []string) error {
+	for _, topic := range topics {
+		form := url.Values{"hub.mode": {"publish"}, "hub.url": {topic}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("hub answered %s for %s", resp.Status, topic)
+		}
+	}
+	return nil
+}
+
+// deliver sends the current content of a topic to one subscriber. Failed
+// deliveries are retried by the job queue.
+func (s *websub) deliver(ctx context.Context, job types.Job) error {
+	id, err := jobs.Decode[string](job)
+	if err != nil {
+		return err
+	}
+	sub, err := s.repo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, types.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if sub.Expires <= time.Now().UTC().Format(time.RFC3339) {
+		return nil
+	}
+	feed, err := s.feed.Topic(ctx, sub.Topic)
+	if err != nil {
+		// a tag feed is gone with the last post tagged with it
+		if errors.Is(err, types.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Callback, strings.NewReader(feed.Body))
+	if err != nil {
+		return nil
+	}
+	req.Header.Set("Content-Type", feed.ContentType+"; charset=utf-8")
+	req.Header.Add("Link", `<`+websubHub(config.Get())+`>; rel="hub"`)
+	req.Header.Add("Link", `<`+sub.Topic+`>; rel="self"`)
+	if sub.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(sub.Secret))
+		mac.Write([]byte(feed.Body))
+		req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		return s.repo.Delete(ctx, sub.Callback, sub.Topic)
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("subscriber answered %s", resp.Status)
+	}
+	return nil
+}
@@ -82,3 +82,9 @@ func GetSession(r *http.Request) (*types.Session, error) {
 	}
 	return nil, errors.New("user is not logged in")
 }
+
+// IsAdmin tells whether r comes from a logged in admin
+func IsAdmin(r *http.Request) bool {
+	s, err := GetSession(r)
+	return err == nil && s.IsAuthenticated && s.IsAdmin
+}
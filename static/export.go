@@ -0,0 +1,289 @@
+// Package static renders the public part of the site to plain files that
+// can be served by any static host.
+package static
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/markdown"
+	"github.com/yosa12978/echoes/services"
+	"github.com/yosa12978/echoes/types"
+	"github.com/yosa12978/echoes/utils"
+)
+
+// pageSize matches the number of posts the blog loads at once
+const pageSize = 20
+
+type Services struct {
+	Posts    services.Post
+	Comments services.Comment
+	Links    services.Link
+	Profile  services.Profile
+	Announce services.Announce
+	Feed     services.Feed
+	Media    services.Media
+	Preview  services.Preview
+	Markdown *markdown.Renderer
+}
+
+type Report struct {
+	Pages  int
+	Posts  int
+	Assets int
+	Media  int
+}
+
+type exporter struct {
+	Services
+	dir    string
+	report Report
+}
+
+// Export writes the home page, the blog, every published post with its
+// comments, the feed, the assets and uploaded media to dir. Pages are written as
+// <path>/index.html, so the site has to be served from the root of a domain.
+func Export(ctx context.Context, svc Services, dir string) (*Report, error) {
+	e := &exporter{Services: svc, dir: dir}
+	steps := []func(context.Context) error{
+		e.index,
+		e.blog,
+		e.feed,
+		e.notFound,
+		e.assets,
+		e.media,
+	}
+	for _, step := range steps {
+		if err := step(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &e.report, nil
+}
+
+func (e *exporter) index(ctx context.Context) error {
+	profile, err := e.Profile.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("profile: %w", err)
+	}
+	announce, err := e.Announce.Get(ctx)
+	if errors.Is(err, types.ErrNotFound) {
+		announce, err = nil, nil
+	}
+	if err != nil {
+		return fmt.Errorf("announce: %w", err)
+	}
+	links, err := e.Links.GetLinks(ctx)
+	if err != nil {
+		return fmt.Errorf("links: %w", err)
+	}
+	view := types.IndexView{Profile: profile, Announce: announce, Links: links}
+	return e.page("index.html", "index", "", types.Meta{URL: config.Get().URL("/")}, view)
+}
+
+// blog writes /blog, /blog/page/<n> and the posts listed on them
+func (e *exporter) blog(ctx context.Context) error {
+	for page := 1; ; page++ {
+		posts, err := e.Posts.GetPostsPaged(ctx, page, pageSize)
+		if err != nil {
+			return fmt.Errorf("posts page %d: %w", page, err)
+		}
+		view := types.BlogView{Posts: posts}
+		if page > 1 {
+			view.PrevURL = blogURL(page - 1)
+		}
+		if posts.HasNext {
+			view.NextURL = blogURL(page + 1)
+		}
+		// pagination links are rendered by the view
+		listed := *posts
+		listed.HasNext = false
+		view.Posts = &listed
+		if err := e.page(filepath.Join(blogURL(page), "index.html"), "blog", "blog", types.Meta{URL: config.Get().URL(blogURL(page))}, view); err != nil {
+			return err
+		}
+		for _, post := range posts.Content {
+			if err := e.post(ctx, post); err != nil {
+				return err
+			}
+		}
+		if !posts.HasNext {
+			return nil
+		}
+	}
+}
+
+func blogURL(page int) string {
+	if page == 1 {
+		return "/blog"
+	}
+	return fmt.Sprintf("/blog/page/%d", page)
+}
+
+func (e *exporter) post(ctx context.Context, post types.Post) error {
+	comments := types.CommentsInfo{PostId: post.Id}
+	cursor := ""
+	for {
+		page, err := e.Comments.GetPostCommentsAfter(ctx, post.Id, cursor, pageSize)
+		if err != nil {
+			return fmt.Errorf("comments of post %s: %w", post.Id, err)
+		}
+		comments.Content = append(comments.Content, page.Content...)
+		if !page.HasNext || page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	post.Comments = len(comments.Content)
+	view := types.PostView{Id: post.Id, Post: &post, Comments: &comments}
+	e.report.Posts++
+	meta := utils.PostMeta(post, config.Get().URL)
+	if utils.PostImage(post) == "" {
+		image, _, err := e.Preview.PostPreview(ctx, post.Id)
+		if err != nil {
+			return fmt.Errorf("preview of post %s: %w", post.Id, err)
+		}
+		if err := e.write(filepath.Join("posts", post.Id, "og.png"), image); err != nil {
+			return err
+		}
+	}
+	return e.page(filepath.Join("posts", post.Id, "index.html"), "post", "blog - "+post.Title, meta, view)
+}
+
+// feed writes the feed in every format, at the paths the server uses
+func (e *exporter) feed(ctx context.Context) error {
+	for _, format := range services.FeedFormats {
+		feed, err := e.Feed.GenerateFeed(ctx, format)
+		if err != nil {
+			return fmt.Errorf("%s feed: %w", format, err)
+		}
+		if err := e.write(strings.TrimPrefix(format.Path(), "/"), []byte(feed.Body)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notFound writes 404.html, which most static hosts serve for missing pages
+func (e *exporter) notFound(ctx context.Context) error {
+	return e.page("404.html", "err404", "Error 404", types.Meta{}, nil)
+}
+
+func (e *exporter) assets(ctx context.Context) error {
+	err := filepath.WalkDir("assets", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if err := copyFile(path, filepath.Join(e.dir, path)); err != nil {
+			return err
+		}
+		e.report.Assets++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// the server generates the stylesheet of highlighted code
+	var css bytes.Buffer
+	if err := e.Markdown.WriteCSS(&css); err != nil {
+		return err
+	}
+	e.report.Assets++
+	return e.write(filepath.Join("assets", "css", "highlight.css"), css.Bytes())
+}
+
+// media copies uploads served by echoes. Stores with a public address of
+// their own keep serving them.
+func (e *exporter) media(ctx context.Context) error {
+	for page := 1; ; page++ {
+		media, err := e.Media.GetMedia(ctx, page, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, m := range media.Content {
+			if !strings.HasPrefix(m.URL, "/") {
+				continue
+			}
+			if err := e.copyMedia(ctx, m); err != nil {
+				return fmt.Errorf("copying %s: %w", m.Name, err)
+			}
+			e.report.Media++
+		}
+		if !media.HasNext {
+			return nil
+		}
+	}
+}
+
+// copyMedia copies an upload and its variants
+func (e *exporter) copyMedia(ctx context.Context, m types.Media) error {
+	if err := e.copyUpload(ctx, m.Key, m.URL); err != nil {
+		return err
+	}
+	for _, v := range m.Variants {
+		if err := e.copyUpload(ctx, v.Key, v.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *exporter) copyUpload(ctx context.Context, key, url string) error {
+	obj, err := e.Media.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return err
+	}
+	return e.write(filepath.FromSlash(strings.TrimPrefix(url, "/")), data)
+}
+
+// page renders a view to name. Canonical urls in meta are on website.url,
+// they are left out when it isn't set.
+func (e *exporter) page(name, view, title string, meta types.Meta, payload any) error {
+	var buf bytes.Buffer
+	if err := utils.RenderStaticView(&buf, view, title, meta, payload); err != nil {
+		return fmt.Errorf("rendering %s: %w", name, err)
+	}
+	e.report.Pages++
+	return e.write(name, buf.Bytes())
+}
+
+func (e *exporter) write(name string, data []byte) error {
+	path := filepath.Join(e.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
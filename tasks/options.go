@@ -0,0 +1,82 @@
+package tasks
+
+import "time"
+
+type optionFunc func(*options)
+
+type options struct {
+	name      string
+	workers   int
+	queueSize int
+	retries   int
+	backoff   time.Duration
+	timeout   time.Duration
+}
+
+func defaultOptions() options {
+	return options{
+		name:      "tasks",
+		workers:   4,
+		queueSize: 256,
+		retries:   2,
+		backoff:   200 * time.Millisecond,
+		timeout:   10 * time.Second,
+	}
+}
+
+func newOptions(opts ...optionFunc) options {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithName sets the expvar name the runner metrics are published under.
+func WithName(name string) optionFunc {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+func WithWorkers(n int) optionFunc {
+	return func(o *options) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+func WithQueueSize(n int) optionFunc {
+	return func(o *options) {
+		if n > 0 {
+			o.queueSize = n
+		}
+	}
+}
+
+// WithRetries sets how many times a failed task is tried again, 0 runs
+// every task once.
+func WithRetries(n int) optionFunc {
+	return func(o *options) {
+		if n >= 0 {
+			o.retries = n
+		}
+	}
+}
+
+func WithBackoff(d time.Duration) optionFunc {
+	return func(o *options) {
+		if d > 0 {
+			o.backoff = d
+		}
+	}
+}
+
+func WithTimeout(d time.Duration) optionFunc {
+	return func(o *options) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yosa12978/echoes/logging"
+)
+
+var (
+	ErrQueueFull    = errors.New("task queue is full")
+	ErrRunnerClosed = errors.New("task runner is closed")
+)
+
+// Task is a unit of background work. The context passed to it is owned by
+// the runner, so tasks must never capture the request context.
+type Task func(ctx context.Context) error
+
+type Runner interface {
+	// Submit enqueues task without blocking. It fails with ErrQueueFull
+	// when the queue is saturated and with ErrRunnerClosed after Shutdown.
+	Submit(name string, task Task) error
+	// Shutdown stops accepting new tasks and waits until the queue is
+	// drained or ctx is done.
+	Shutdown(ctx context.Context) error
+	Stats() Stats
+}
+
+type Stats struct {
+	Queued    int   `json:"queued"`
+	Processed int64 `json:"processed"`
+	Failed    int64 `json:"failed"`
+	Retried   int64 `json:"retried"`
+	Dropped   int64 `json:"dropped"`
+	Panics    int64 `json:"panics"`
+}
+
+type job struct {
+	name string
+	task Task
+}
+
+type runner struct {
+	opts   options
+	logger logging.Logger
+
+	queue  chan job
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	processed expvar.Int
+	failed    expvar.Int
+	retried   expvar.Int
+	dropped   expvar.Int
+	panics    expvar.Int
+}
+
+func NewRunner(logger logging.Logger, opts ...optionFunc) Runner {
+	o := newOptions(opts...)
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &runner{
+		opts:   o,
+		logger: logger,
+		queue:  make(chan job, o.queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	r.publish()
+	for i := 0; i < o.workers; i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+	return r
+}
+
+// publish exposes runner metrics through expvar (served at /debug/vars).
+func (r *runner) publish() {
+	if expvar.Get(r.opts.name) != nil {
+		return
+	}
+	m := expvar.NewMap(r.opts.name)
+	m.Set("queued", expvar.Func(func() any { return len(r.queue) }))
+	m.Set("processed", &r.processed)
+	m.Set("failed", &r.failed)
+	m.Set("retried", &r.retried)
+	m.Set("dropped", &r.dropped)
+	m.Set("panics", &r.panics)
+}
+
+func (r *runner) Submit(name string, task Task) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		r.dropped.Add(1)
+		return ErrRunnerClosed
+	}
+	select {
+	case r.queue <- job{name: name, task: task}:
+		return nil
+	default:
+		r.dropped.Add(1)
+		return fmt.Errorf("%s: %w", name, ErrQueueFull)
+	}
+}
+
+func (r *runner) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		r.cancel()
+		return nil
+	case <-ctx.Done():
+		r.cancel()
+		r.logger.Error("task runner shutdown timed out", "queued", len(r.queue))
+		return ctx.Err()
+	}
+}
+
+func (r *runner) Stats() Stats {
+	return Stats{
+		Queued:    len(r.queue),
+		Processed: r.processed.Value(),
+		Failed:    r.failed.Value(),
+		Retried:   r.retried.Value(),
+		Dropped:   r.dropped.Value(),
+		Panics:    r.panics.Value(),
+	}
+}
+
+func (r *runner) work() {
+	defer r.wg.Done()
+	for j := range r.queue {
+		r.run(j)
+	}
+}
+
+func (r *runner) run(j job) {
+	backoff := r.opts.backoff
+	var err error
+	for attempt := 0; attempt <= r.opts.retries; attempt++ {
+		if attempt > 0 {
+			r.retried.Add(1)
+			select {
+			case <-time.After(backoff):
+			case <-r.ctx.Done():
+				r.failed.Add(1)
+				r.logger.Error("task aborted", "task", j.name, "error", r.ctx.Err().Error())
+				return
+			}
+			backoff *= 2
+		}
+		err = r.attempt(j)
+		if err == nil {
+			r.processed.Add(1)
+			return
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			break
+		}
+	}
+	r.failed.Add(1)
+	r.logger.Error("task failed", "task", j.name, "error", err.Error())
+}
+
+func (r *runner) attempt(j job) (err error) {
+	ctx, cancel := context.WithTimeout(r.ctx, r.opts.timeout)
+	defer cancel()
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.panics.Add(1)
+			err = Permanent(fmt.Errorf("panic: %+v", rec))
+		}
+	}()
+	return j.task(ctx)
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
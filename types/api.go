@@ -0,0 +1,143 @@
+package types
+
+// The types below are the bodies of the /api/v1 JSON API. They are kept
+// apart from the stored types, so those can change without breaking
+// clients.
+
+// APIError is the body of every failed response
+type APIError struct {
+	Error APIErrorBody `json:"error"`
+}
+
+type APIErrorBody struct {
+	// Code is a stable name of the error, like "not_found"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Problems maps fields of the request to what's wrong with them
+	Problems map[string]string `json:"problems,omitempty"`
+}
+
+// APIData wraps a single resource
+type APIData[T any] struct {
+	Data T `json:"data"`
+}
+
+// APIPage wraps a page of resources
+type APIPage[T any] struct {
+	Data []T         `json:"data"`
+	Page APIPageInfo `json:"page"`
+}
+
+// APIPageInfo tells how to get the next page. Pages are either numbered,
+// with NextPage and Total, or follow a cursor, with NextCursor.
+type APIPageInfo struct {
+	Size       int    `json:"size"`
+	HasNext    bool   `json:"has_next"`
+	NextPage   int    `json:"next_page,omitempty"`
+	Total      int    `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewAPIPage converts the content of page with convert
+func NewAPIPage[T, R any](page *Page[T], convert func(T) R) APIPage[R] {
+	res := APIPage[R]{Data: make([]R, len(page.Content))}
+	for i, item := range page.Content {
+		res.Data[i] = convert(item)
+	}
+	res.Page = APIPageInfo{
+		Size:       page.Size,
+		HasNext:    page.HasNext,
+		NextPage:   page.NextPage,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+	}
+	if !page.HasNext {
+		res.Page.NextPage = 0
+		res.Page.NextCursor = ""
+	}
+	return res
+}
+
+type APIPost struct {
+	Id      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// HTML is Content rendered the way the site shows it
+	HTML     string   `json:"html"`
+	Created  string   `json:"created"`
+	Pinned   bool     `json:"pinned"`
+	Tweet    bool     `json:"tweet"`
+	Tags     []string `json:"tags"`
+	Draft    bool     `json:"draft"`
+	TOC      bool     `json:"toc"`
+	Comments int      `json:"comments"`
+}
+
+func NewAPIPost(post Post) APIPost {
+	tags := post.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return APIPost{
+		Id:       post.Id,
+		Title:    post.Title,
+		Content:  post.Content,
+		HTML:     string(post.HTML),
+		Created:  post.Created,
+		Pinned:   post.Pinned,
+		Tweet:    post.Tweet,
+		Tags:     tags,
+		Draft:    post.Draft,
+		TOC:      post.TOC,
+		Comments: post.Comments,
+	}
+}
+
+// APIComment leaves out the email of the commenter, the API is public and
+// easier to harvest than the pages
+type APIComment struct {
+	Id      string `json:"id"`
+	PostId  string `json:"post_id"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Created string `json:"created"`
+}
+
+func NewAPIComment(comment Comment) APIComment {
+	return APIComment{
+		Id:      comment.Id,
+		PostId:  comment.PostId,
+		Name:    comment.Name,
+		Content: comment.Content,
+		Created: comment.Created,
+	}
+}
+
+type APILink struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Icon    string `json:"icon"`
+	Place   int    `json:"place"`
+	Created string `json:"created"`
+}
+
+func NewAPILink(link Link) APILink {
+	return APILink{
+		Id:      link.Id,
+		Name:    link.Name,
+		URL:     link.URL,
+		Icon:    link.Icon,
+		Place:   link.Place,
+		Created: link.Created,
+	}
+}
+
+type APIAnnounce struct {
+	Content string `json:"content"`
+	Date    string `json:"date"`
+}
+
+func NewAPIAnnounce(announce Announce) APIAnnounce {
+	return APIAnnounce{Content: announce.Content, Date: announce.Date}
+}
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BackupSchemaVersion is bumped whenever the archive layout changes in a way
+// older versions can't import.
+const BackupSchemaVersion = 1
+
+const (
+	// ImportMerge keeps existing content and adds what's missing
+	ImportMerge = "merge"
+	// ImportReplace removes posts, comments, links and the announcement
+	// before importing. Accounts are always merged.
+	ImportReplace = "replace"
+)
+
+type BackupManifest struct {
+	SchemaVersion int            `json:"schema_version"`
+	Created       string         `json:"created"`
+	Counts        map[string]int `json:"counts"`
+}
+
+type BackupReport struct {
+	Mode     string
+	Imported map[string]int
+	Skipped  map[string]int
+	// Remapped counts records that got a new id because theirs was taken
+	Remapped map[string]int
+	Errors   []string
+}
+
+func NewBackupReport(mode string) *BackupReport {
+	return &BackupReport{
+		Mode:     mode,
+		Imported: make(map[string]int),
+		Skipped:  make(map[string]int),
+		Remapped: make(map[string]int),
+	}
+}
+
+func (r BackupReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s import: ", r.Mode)
+	parts := []string{}
+	for _, entity := range []string{"posts", "comments", "links", "accounts", "announce"} {
+		parts = append(parts, fmt.Sprintf("%s %d imported, %d skipped, %d remapped",
+			entity, r.Imported[entity], r.Skipped[entity], r.Remapped[entity]))
+	}
+	b.WriteString(strings.Join(parts, "; "))
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(&b, "; %d error(s)", len(r.Errors))
+	}
+	return b.String()
+}
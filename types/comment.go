@@ -15,6 +15,12 @@ type Comment struct {
 	PostId  string
 }
 
+// LatestComment is a comment along with the title of the post it's on
+type LatestComment struct {
+	Comment
+	PostTitle string
+}
+
 type CommentCreateDto struct {
 	Name    string
 	Email   string
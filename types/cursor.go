@@ -0,0 +1,45 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// PostCursor points at the last post of a page in
+// (pinned DESC, created DESC, id DESC) order.
+type PostCursor struct {
+	Pinned  bool   `json:"p"`
+	Created string `json:"c"`
+	Id      string `json:"i"`
+}
+
+// CommentCursor points at the last comment of a page in
+// (created DESC, id DESC) order.
+type CommentCursor struct {
+	Created string `json:"c"`
+	Id      string `json:"i"`
+}
+
+// EncodeCursor turns a cursor into an opaque url-safe token.
+func EncodeCursor(cursor any) string {
+	j, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(j)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor. An empty token
+// means "first page" and yields nil.
+func DecodeCursor[T any](token string) (*T, error) {
+	if token == "" {
+		return nil, nil
+	}
+	j, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, NewErrBadRequest(errors.New("malformed cursor"))
+	}
+	var cursor T
+	if err := json.Unmarshal(j, &cursor); err != nil {
+		return nil, NewErrBadRequest(errors.New("malformed cursor"))
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// Feed is a generated feed document
+type Feed struct {
+	Body        string `json:"body"`
+	ContentType string `json:"content_type"`
+	// ETag is a hash of Body, Modified is when the newest post was published
+	ETag     string    `json:"etag"`
+	Modified time.Time `json:"modified"`
+	// Self is the absolute url of the feed and Hub the WebSub hub it's
+	// published to. Both are empty when they aren't advertised.
+	Self string `json:"self,omitempty"`
+	Hub  string `json:"hub,omitempty"`
+}
@@ -0,0 +1,20 @@
+package types
+
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDead    = "dead"
+)
+
+type Job struct {
+	Id          string
+	Kind        string
+	Payload     string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	RunAt       string
+	Created     string
+	Updated     string
+}
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Media is an uploaded file
+type Media struct {
+	Id string
+	// Name is the name of the file on the computer it was uploaded from
+	Name string
+	// Key is where the file is kept in the media store
+	Key         string
+	ContentType string
+	Size        int64
+	Created     string
+	// Width and Height are known for images, they are 0 otherwise
+	Width  int
+	Height int
+	// Variants are resized and WebP copies of an image
+	Variants []MediaVariant
+	// URL is the address the file is served at, filled in by the media
+	// service
+	URL string
+}
+
+// MediaVariant is a copy of an uploaded image made for smaller screens or
+// browsers that support a smaller format
+type MediaVariant struct {
+	Key         string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int64
+	URL         string
+}
+
+func (m Media) IsImage() bool {
+	return strings.HasPrefix(m.ContentType, "image/")
+}
+
+// Thumbnail is the URL of the smallest copy of an image
+func (m Media) Thumbnail() string {
+	url, width := m.URL, m.Width
+	for _, v := range m.Variants {
+		if v.Width < width {
+			url, width = v.URL, v.Width
+		}
+	}
+	return url
+}
+
+// Markdown is a snippet to paste into the post editor
+func (m Media) Markdown() string {
+	name := strings.NewReplacer("[", "", "]", "", "\n", " ").Replace(m.Name)
+	if m.IsImage() {
+		return fmt.Sprintf("![%s](%s)", name, m.URL)
+	}
+	return fmt.Sprintf("[%s](%s)", name, m.URL)
+}
+
+// HumanSize formats Size like 1.5 MB
+func (m Media) HumanSize() string {
+	const unit = 1024
+	if m.Size < unit {
+		return fmt.Sprintf("%d B", m.Size)
+	}
+	div, exp := int64(unit), 0
+	for n := m.Size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(m.Size)/float64(div), "KMGT"[exp])
+}
@@ -6,4 +6,6 @@ type Page[T interface{}] struct {
 	NextPage int
 	Content  []T
 	Total    int
+	// NextCursor is set instead of NextPage/Total by keyset pagination
+	NextCursor string
 }
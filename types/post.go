@@ -2,6 +2,7 @@ package types
 
 import (
 	"context"
+	"html/template"
 	"strings"
 	"time"
 
@@ -9,13 +10,53 @@ import (
 )
 
 type Post struct {
-	Id       string
-	Title    string
-	Content  string
-	Created  string
-	Pinned   bool
-	Tweet    bool
+	Id      string
+	Title   string
+	Content string
+	Created string
+	Pinned  bool
+	Tweet   bool
+	Tags    []string
+	// Draft posts are left out of listings, search and feeds
+	Draft    bool
 	Comments int
+	// TOC shows a table of contents whatever the number of headings
+	TOC bool
+	// HTML is the rendered and sanitized Content, filled in by the post
+	// service
+	HTML template.HTML
+	// TOCHTML is the table of contents of Content, empty when the post
+	// doesn't show one
+	TOCHTML template.HTML
+}
+
+// JoinTags encodes tags as ",a,b," so a single tag can be matched with
+// LIKE '%,tag,%' in any database.
+func JoinTags(tags []string) string {
+	tags = NormalizeTags(tags)
+	if len(tags) == 0 {
+		return ""
+	}
+	return "," + strings.Join(tags, ",") + ","
+}
+
+func SplitTags(s string) []string {
+	return NormalizeTags(strings.Split(s, ","))
+}
+
+// NormalizeTags lowercases and trims tags, and drops empty and repeated ones.
+func NormalizeTags(tags []string) []string {
+	res := []string{}
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, ",", " ")))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		res = append(res, tag)
+	}
+	return res
 }
 
 func NewPost(title, content string, tweet bool) Post {
@@ -34,6 +75,7 @@ type PostCreateDto struct {
 	Title   string
 	Content string
 	Tweet   string
+	TOC     string
 }
 
 func (p PostCreateDto) Validate(ctx context.Context) (PostCreateDto, map[string]string, bool) {
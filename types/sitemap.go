@@ -0,0 +1,10 @@
+package types
+
+import "time"
+
+// Sitemap is a generated sitemap or sitemap index
+type Sitemap struct {
+	Body string `json:"body"`
+	// Modified is the newest lastmod in Body
+	Modified time.Time `json:"modified"`
+}
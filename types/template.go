@@ -6,8 +6,49 @@ type CommentsInfo struct {
 }
 
 type Templ struct {
-	Title   string
-	Logo    string
-	BgImg   string
-	Payload interface{}
+	Title     string
+	SiteName  string
+	Logo      string
+	BgImg     string
+	FeedTitle string
+	Meta      Meta
+	Payload   interface{}
+}
+
+// Meta describes a page to search engines and link previews. Pages without
+// a Title only get the canonical link.
+type Meta struct {
+	// URL is the canonical address of the page
+	URL         string
+	Type        string
+	Title       string
+	Description string
+	Image       string
+	// Published, Author and Tags describe articles
+	Published string
+	Author    string
+	Tags      []string
+	// JSONLD is written out as the structured data of the page
+	JSONLD any
+}
+
+// IndexView, BlogView and PostView are the payloads of the views. Without
+// content the views load it with htmx, the static site export fills it in.
+type IndexView struct {
+	Profile  *Profile
+	Announce *Announce
+	Links    []Link
+}
+
+type BlogView struct {
+	Posts *Page[Post]
+	// PrevURL and NextURL link the pages of the exported blog
+	PrevURL string
+	NextURL string
+}
+
+type PostView struct {
+	Id       string
+	Post     *Post
+	Comments *CommentsInfo
 }
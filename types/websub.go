@@ -0,0 +1,24 @@
+package types
+
+// Subscription is a callback that the built-in WebSub hub delivers a feed
+// to whenever it changes
+type Subscription struct {
+	Id       string
+	Callback string
+	Topic    string
+	// Secret signs deliveries, subscribers may leave it empty
+	Secret  string
+	Expires string
+	Created string
+}
+
+// SubscriptionRequest is a subscribe or unsubscribe request waiting for the
+// subscriber to confirm it
+type SubscriptionRequest struct {
+	Mode     string `json:"mode"`
+	Callback string `json:"callback"`
+	Topic    string `json:"topic"`
+	Secret   string `json:"secret,omitempty"`
+	// Lease is in seconds
+	Lease int `json:"lease"`
+}
@@ -35,3 +35,8 @@ func ReadJsonAndValidate[T validation.Validatable[T]](ctx context.Context, body
 	}
 	return res, nil, nil
 }
+
+// WriteAPIError writes the error envelope of the JSON API
+func WriteAPIError(w http.ResponseWriter, status int, code, message string) error {
+	return WriteJson(w, types.APIError{Error: types.APIErrorBody{Code: code, Message: message}}, status)
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"html/template"
+
+	"github.com/yosa12978/echoes/markdown"
+)
+
+// Markdown renders short snippets like the announcement in templates.
+// Posts come with their HTML already rendered by the post service.
+func Markdown(src string) template.HTML {
+	res, err := markdown.Default().Render(src)
+	if err != nil {
+		return template.HTML(template.HTMLEscapeString(src))
+	}
+	return template.HTML(res.HTML)
+}
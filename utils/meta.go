@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yosa12978/echoes/config"
+	"github.com/yosa12978/echoes/types"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// excerptLength is about what link previews and search results show
+const excerptLength = 200
+
+// PostMeta describes a rendered post for link previews and search engines.
+// absolute turns a path on the site into a url.
+func PostMeta(post types.Post, absolute func(path string) string) types.Meta {
+	cfg := config.Get()
+	excerpt, image := summarize(string(post.HTML))
+	if excerpt == "" {
+		excerpt = post.Title
+	}
+	if image == "" {
+		image = PreviewPath(post.Id)
+	}
+	if strings.HasPrefix(image, "/") && !strings.HasPrefix(image, "//") {
+		image = absolute(image)
+	}
+	author := cfg.Feed.Author
+	if author == "" {
+		author = cfg.Profile.Name
+	}
+	meta := types.Meta{
+		URL:         absolute("/posts/" + url.PathEscape(post.Id)),
+		Type:        "article",
+		Title:       post.Title,
+		Description: excerpt,
+		Image:       image,
+		Published:   post.Created,
+		Author:      author,
+		Tags:        post.Tags,
+	}
+
+	ld := map[string]any{
+		"@context":         "https://schema.org",
+		"@type":            "BlogPosting",
+		"headline":         post.Title,
+		"description":      excerpt,
+		"url":              meta.URL,
+		"mainEntityOfPage": meta.URL,
+		"datePublished":    post.Created,
+		"author": map[string]any{
+			"@type": "Person",
+			"name":  author,
+			"url":   absolute("/"),
+		},
+		"publisher": map[string]any{
+			"@type": "Organization",
+			"name":  cfg.Website.Title,
+			"url":   absolute("/"),
+		},
+	}
+	if image != "" {
+		ld["image"] = image
+	}
+	if len(post.Tags) > 0 {
+		ld["keywords"] = strings.Join(post.Tags, ", ")
+	}
+	meta.JSONLD = ld
+	return meta
+}
+
+// PreviewPath is where the generated preview image of a post is served
+func PreviewPath(id string) string {
+	return "/posts/" + url.PathEscape(id) + "/og.png"
+}
+
+// PostImage returns the source of the first image of a rendered post, empty
+// when it has none and gets a generated preview
+func PostImage(post types.Post) string {
+	_, image := summarize(string(post.HTML))
+	return image
+}
+
+// summarize returns the start of the text of rendered and the source of
+// its first image. Code blocks are left out of the text, they make for poor
+// descriptions.
+func summarize(rendered string) (excerpt, image string) {
+	var text strings.Builder
+	skip := 0
+	z := html.NewTokenizer(strings.NewReader(rendered))
+	for text.Len() < excerptLength*2 || image == "" {
+		switch z.Next() {
+		case html.ErrorToken:
+			return truncate(text.String(), excerptLength), image
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag := z.Token()
+			switch tag.DataAtom {
+			case atom.Pre, atom.Script, atom.Style:
+				skip++
+			case atom.Img:
+				for _, attr := range tag.Attr {
+					if attr.Key == "src" && image == "" {
+						image = attr.Val
+					}
+				}
+			case atom.P, atom.Br, atom.Li, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+				text.WriteByte(' ')
+			}
+		case html.EndTagToken:
+			switch z.Token().DataAtom {
+			case atom.Pre, atom.Script, atom.Style:
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				text.Write(z.Text())
+			}
+		}
+	}
+	return truncate(text.String(), excerptLength), image
+}
+
+// truncate collapses whitespace in text and cuts it at the last word that
+// fits in n characters
+func truncate(text string, n int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	cut := string([]rune(text)[:n])
+	if i := strings.LastIndex(cut, " "); i > n/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, ",.;:-– ") + "…"
+}
@@ -5,20 +5,54 @@ import (
 	"html/template"
 	"io"
 	"log"
+	"net/url"
 
 	"github.com/yosa12978/echoes/config"
 	"github.com/yosa12978/echoes/types"
 )
 
-var cfg = config.Get()
+var blockFiles = []string{
+	"templates/blocks/posts.html",
+	"templates/blocks/links.html",
+	"templates/blocks/profile.html",
+	"templates/blocks/announce.html",
+	"templates/blocks/alert.html",
+	"templates/blocks/comments.html",
+	"templates/blocks/jobs.html",
+	"templates/blocks/uploads.html",
+}
 
-func RenderView(w io.Writer, view string, title string, payload any) error {
+// funcs are available in every view and block. static is true while the
+// site is exported to plain files, where nothing under /api is reachable.
+func funcs(static bool) template.FuncMap {
+	return template.FuncMap{
+		"markdown": Markdown,
+		"static":   func() bool { return static },
+		// pathEscape makes a value one segment of a url path
+		"pathEscape": url.PathEscape,
+	}
+}
+
+// RenderView renders a page. meta.URL is the absolute url search engines
+// should index it under, pages that aren't indexed leave meta empty.
+func RenderView(w io.Writer, view string, title string, meta types.Meta, payload any) error {
+	return renderView(w, view, title, meta, payload, false)
+}
+
+// RenderStaticView renders a view for the static site export. Views get
+// their content in the payload instead of loading it with htmx.
+func RenderStaticView(w io.Writer, view string, title string, meta types.Meta, payload any) error {
+	return renderView(w, view, title, meta, payload, true)
+}
+
+func renderView(w io.Writer, view string, title string, meta types.Meta, payload any, static bool) error {
 	templPath := fmt.Sprintf("templates/views/%s.html", view)
-	templ, err := template.ParseFiles(
+	files := append([]string{
 		templPath,
 		"templates/top.html",
 		"templates/bottom.html",
-	)
+	}, blockFiles...)
+	templ, err := template.New(view + ".html").Funcs(funcs(static)).ParseFiles(files...)
 	if err != nil {
 		log.Println(err.Error())
 		return err
@@ -26,25 +60,22 @@ func RenderView(w io.Writer, view string, title string, payload any) error {
 	if title != "" {
 		title = "/" + title
 	}
+	cfg := config.Get()
 	data := types.Templ{
-		Title:   cfg.Website.Title + title,
-		Logo:    cfg.Website.Logo,
-		BgImg:   cfg.Website.BgImg,
-		Payload: payload,
+		Title:     cfg.Website.Title + title,
+		SiteName:  cfg.Website.Title,
+		Logo:      cfg.Website.Logo,
+		BgImg:     cfg.Website.BgImg,
+		FeedTitle: cfg.Feed.Title,
+		Meta:      meta,
+		Payload:   payload,
 	}
 	return templ.Execute(w, data)
 }
 
 func RenderBlock(w io.Writer, name string, payload any) error {
 	templ := template.Must(
-		template.ParseFiles(
-			"templates/blocks/posts.html",
-			"templates/blocks/links.html",
-			"templates/blocks/profile.html",
-			"templates/blocks/announce.html",
-			"templates/blocks/alert.html",
-			"templates/blocks/comments.html",
-		),
+		template.New("posts.html").Funcs(funcs(false)).ParseFiles(blockFiles...),
 	)
 	return templ.ExecuteTemplate(w, name, payload)
 }
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"net/http"
+
+	"github.com/yosa12978/echoes/config"
+)
+
+// SiteURL returns the absolute url of path on website.url or, when it isn't
+// set, on the host r was sent to
+func SiteURL(r *http.Request, path string) string {
+	if url := config.Get().URL(path); url != "" {
+		return url
+	}
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}